@@ -0,0 +1,195 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"time"
+
+	"github.com/dmaharana/clone-git-repo/internal/pkg/config"
+	"github.com/dmaharana/clone-git-repo/internal/pkg/csv"
+	"github.com/dmaharana/clone-git-repo/internal/pkg/git"
+	"github.com/dmaharana/clone-git-repo/internal/pkg/logger"
+	"github.com/dmaharana/clone-git-repo/internal/repostatus"
+)
+
+const (
+	ResultFileName = "clone-git-repo-result.csv"
+)
+
+var cloneCommand = &command{
+	name:    "clone",
+	summary: "Clone the repositories",
+	help: `Clones every repository listed in the CSV file or discovered on a hosting
+provider into the clone directory, then prints a status table and writes it
+to ` + ResultFileName + `. Existing clones are replaced unless -sync is given.`,
+	flags: func(f *config.Flags, _ *flag.FlagSet) {
+		f.Repositories()
+		f.Credentials()
+		f.Concurrency()
+		f.Clone()
+		f.Sync()
+	},
+	run: runClone,
+}
+
+var syncCommand = &command{
+	name:    "sync",
+	summary: "Fetch updates into existing clones, cloning missing ones",
+	help: `Fetches all branches and tags into the existing clones and fast-forwards
+their local branches, cloning the repositories that are not on disk yet.
+Same as clone -sync.`,
+	flags: func(f *config.Flags, _ *flag.FlagSet) {
+		f.Repositories()
+		f.Credentials()
+		f.Concurrency()
+		f.Clone()
+	},
+	run: func(cfg *config.Config, args []string) error {
+		cfg.Sync = true
+		return runClone(cfg, args)
+	},
+}
+
+// runClone clones or syncs every repository and reports the results
+func runClone(cfg *config.Config, args []string) error {
+	if err := noArguments(args); err != nil {
+		return err
+	}
+
+	repositories, repoDirs, err := loadRepositories(cfg)
+	if err != nil {
+		return err
+	}
+
+	// Clone repositories in parallel, keeping results in input order
+	cloneStatus := cloneRepositories(repositories, repoDirs, cfg)
+
+	// Print status table
+	repostatus.PrintStatusTable(cloneStatus)
+
+	// Write status to CSV file
+	if err := repostatus.WriteStatusToCSV(cloneStatus, ResultFileName); err != nil {
+		log.Error("Failed to write status to CSV file", "file", ResultFileName, logger.KeyError, err)
+	}
+	return nil
+}
+
+// cloneRepositories clones every repository using a bounded pool of workers.
+// The returned statuses are in the same order as the input repositories.
+func cloneRepositories(repositories []csv.RepoSpec, repoDirs []string, cfg *config.Config) []*repostatus.RepoStatus {
+	// each worker writes only to its own index, so no locking is needed
+	results := make([]*repostatus.RepoStatus, len(repositories))
+	parallel(len(repositories), cfg.Concurrency, func(i int) {
+		results[i] = processRepository(repositories[i], repoDirs[i], cfg)
+	})
+	return results
+}
+
+// processRepository clones a single repository, retrying on known errors,
+// and returns its status
+func processRepository(spec csv.RepoSpec, repoDir string, cfg *config.Config) *repostatus.RepoStatus {
+	url := spec.URL
+	rs := &repostatus.RepoStatus{
+		RepoPath: url,
+		Mode:     cfg.Mode,
+		Labels:   spec.Tags,
+	}
+	if spec.Mode != "" {
+		rs.Mode = spec.Mode
+	}
+	rlog := log.With(logger.KeyRepo, url, "mode", rs.Mode)
+	if rs.Mode == config.ModeSkip {
+		rlog.Info("Skipping repository")
+		return rs
+	}
+	start := time.Now()
+
+	opts, err := repositoryOptions(spec, cfg)
+	if err != nil {
+		rs.ErrorClass = string(git.Classify(err))
+		rs.Error = err.Error()
+		rlog.Error("Failed to prepare repository", logger.KeyErrorClass, rs.ErrorClass, logger.KeyError, err)
+		return rs
+	}
+
+	policy := cfg.Retry
+	for attempt := 1; ; attempt++ {
+		rs.Attempts = attempt
+		err = attemptRepository(url, repoDir, cfg, rs, opts)
+		if err == nil || attempt >= policy.MaxAttempts {
+			break
+		}
+
+		class := git.Classify(err)
+		if !policy.IsRetryable(string(class)) {
+			break
+		}
+
+		delay := policy.Delay(attempt)
+		rlog.Warn("Attempt failed, retrying", logger.KeyAttempt, attempt, "max_attempts", policy.MaxAttempts,
+			logger.KeyErrorClass, class, logger.KeyError, err, "retry_in", delay)
+		time.Sleep(delay)
+	}
+
+	rs.IsCloned = err == nil
+	if !rs.IsCloned {
+		rs.ErrorClass = string(git.Classify(err))
+		rs.Error = err.Error()
+		rs.BranchCount = 0
+		rs.TagCount = 0
+		rlog.Error("Failed to clone repository", logger.KeyAttempt, rs.Attempts, logger.KeyErrorClass, rs.ErrorClass,
+			logger.KeyError, err, logger.KeyDuration, time.Since(start))
+		return rs
+	}
+
+	rlog.Info("Repository done", logger.KeyDir, repoDir, logger.KeyAttempt, rs.Attempts, logger.KeyDuration, time.Since(start))
+	return rs
+}
+
+// attemptRepository syncs or clones a repository once. A directory left in
+// the way is handled within the same attempt, as it is resolved locally and
+// is not worth a retry.
+func attemptRepository(url string, repoDir string, cfg *config.Config, rs *repostatus.RepoStatus, opts *git.Options) error {
+	if canSync(repoDir, cfg, rs) {
+		return syncRepository(url, repoDir, rs, opts)
+	}
+
+	err := cloneRepository(url, repoDir, rs, opts)
+	if git.Classify(err) == git.ErrorAlreadyExists {
+		log.Debug("Directory already exists, replacing it", logger.KeyRepo, url, logger.KeyDir, repoDir)
+		err = handleDirectoryExistsError(url, repoDir, cfg, rs, opts)
+	}
+	return err
+}
+
+// perform git clone, or a mirror clone in mirror mode, and return error
+func cloneRepository(url string, repoDir string, rs *repostatus.RepoStatus, opts *git.Options) error {
+	if rs.Mode == config.ModeMirror {
+		return git.MirrorRepo(url, repoDir, rs, opts)
+	}
+	return git.CloneRepo(url, repoDir, rs, opts)
+}
+
+// canSync reports whether an existing clone in repoDir should be synced
+// rather than replaced. Mirrors update themselves when cloned again.
+func canSync(repoDir string, cfg *config.Config, rs *repostatus.RepoStatus) bool {
+	return cfg.Sync && rs.Mode != config.ModeMirror && git.IsRepository(repoDir)
+}
+
+// fetch updates into an existing clone and return error
+func syncRepository(url string, repoDir string, rs *repostatus.RepoStatus, opts *git.Options) error {
+	return git.SyncRepo(url, repoDir, rs, opts)
+}
+
+// handle if directory already exists, sync it in sync mode, otherwise remove it and try again
+func handleDirectoryExistsError(url string, repoDir string, cfg *config.Config, rs *repostatus.RepoStatus, opts *git.Options) error {
+	if canSync(repoDir, cfg, rs) {
+		return syncRepository(url, repoDir, rs, opts)
+	}
+
+	// Remove the partially cloned directory
+	os.RemoveAll(repoDir)
+
+	// Clone the repository into the directory
+	return cloneRepository(url, repoDir, rs, opts)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dmaharana/clone-git-repo/internal/pkg/config"
+	"github.com/dmaharana/clone-git-repo/internal/pkg/csv"
+)
+
+// discoverOutput is the file the discovered repositories are written to
+var discoverOutput string
+
+var discoverCommand = &command{
+	name:    "discover",
+	summary: "List the repositories without cloning them",
+	help: `Lists the repositories of the configured source, such as a GitHub
+organization or GitLab group, after the include, exclude, topic and
+visibility filters, and writes them as a CSV file that clone accepts with -f.
+Use it to review or pin the list before cloning.`,
+	flags: func(f *config.Flags, set *flag.FlagSet) {
+		f.Repositories()
+		f.Credentials()
+		set.StringVar(&discoverOutput, "o", "-", "Write the repository CSV to `file`, - for standard output")
+	},
+	run: runDiscover,
+}
+
+// runDiscover writes the repositories of the configured source as CSV
+func runDiscover(cfg *config.Config, args []string) error {
+	if err := noArguments(args); err != nil {
+		return err
+	}
+
+	repositories, err := listRepositories(cfg)
+	if err != nil {
+		return err
+	}
+	// a list that clone would reject, such as a GitLab project nested in
+	// the directory of another, fails here rather than when cloning
+	if _, err := planDirectories(repositories, cfg); err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if discoverOutput != "-" {
+		file, err := os.Create(discoverOutput)
+		if err != nil {
+			return fmt.Errorf("failed to create repository file: %w", err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if err := csv.WriteRepositories(w, repositories); err != nil {
+		return fmt.Errorf("failed to write repositories: %w", err)
+	}
+	if discoverOutput != "-" {
+		log.Info("Repositories written", "file", discoverOutput, "count", len(repositories))
+	}
+	return nil
+}
@@ -1,16 +1,20 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/dmaharana/clone-git-repo/internal/pkg/config"
-	"github.com/dmaharana/clone-git-repo/internal/pkg/csv"
 	"github.com/dmaharana/clone-git-repo/internal/pkg/git"
 	"github.com/dmaharana/clone-git-repo/internal/pkg/logger"
-	"github.com/dmaharana/clone-git-repo/internal/repostatus"
+	"github.com/dmaharana/clone-git-repo/metrics"
 )
 
 // Build information. Populated at build-time.
@@ -21,171 +25,204 @@ var (
 	GitBranch string
 )
 
-// create enum for exit codes
-const (
-	CreateDirectoryError = iota + 1
-	AuthenticationError
-	DirectoryExistsError
-	UnknownError
+// defaultCommand runs when no command is given, as before commands existed
+const defaultCommand = "clone"
 
-	AuthenticationErrorString   = "authentication required"
-	DirectoryExistsErrorString  = "repository already exists"
-	ResultFileName              = "clone-git-repo-result.csv"
-	DirectoryExistsErrorMessage = "Directory already exists, remove it and try again"
+var log *logger.Logger
 
-	MaxRetries = 3
-)
+// command is a subcommand of the tool
+type command struct {
+	name    string
+	summary string // one line shown in the command list
+	help    string // shown by -h, after the usage line
+	// flags registers the flags of the command on top of the shared ones
+	flags func(f *config.Flags, set *flag.FlagSet)
+	// run runs the command with the loaded configuration and the arguments
+	// left after the flags
+	run func(cfg *config.Config, args []string) error
+	// standalone commands run without loading the configuration or
+	// opening the log
+	standalone bool
+}
 
-var log *logger.Logger
+// commands lists the commands in the order they are shown
+var commands = []*command{
+	cloneCommand,
+	syncCommand,
+	statusCommand,
+	metricsCommand,
+	discoverCommand,
+	verifyCommand,
+	versionCommand,
+}
 
 func main() {
-	// Parse command-line flags
-	cfg := config.ParseFlags()
+	args := os.Args[1:]
+	name := defaultCommand
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
 
-	// Initialize logger
-	logCfg := &logger.Config{
-		LogDir:     cfg.LogDir,
-		MaxSize:    cfg.LogMaxSize,
-		TimeFormat: "2006-01-02",
+	switch {
+	case len(os.Args) > 1 && isHelpFlag(os.Args[1]):
+		printUsage(os.Stdout)
+		return
+	case name == "help":
+		if len(args) == 0 {
+			printUsage(os.Stdout)
+			return
+		}
+		name, args = args[0], []string{"-h"}
 	}
 
-	var err error
-	log, err = logger.New(logCfg)
-	if err != nil {
-		fmt.Printf("Error initializing logger: %v\n", err)
-		os.Exit(1)
+	cmd := findCommand(name)
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+		printUsage(os.Stderr)
+		os.Exit(2)
 	}
-	defer log.Close()
+	cmd.execute(args)
+}
 
-	// Set log flags
-	log.SetFlags(logger.LstdFlags | logger.Lshortfile)
+// execute parses the flags of the command, loads the configuration, opens
+// the log and runs the command
+func (c *command) execute(args []string) {
+	set := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	set.Usage = func() { c.printHelp(set) }
 
-	// Print version information
-	if Version != "" {
-		fmt.Printf("Version: %s\n", Version)
-		fmt.Printf("Build Time: %s\n", BuildTime)
-		fmt.Printf("Git Commit: %s\n", GitCommit)
-		fmt.Printf("Git Branch: %s\n", GitBranch)
+	var flags *config.Flags
+	if !c.standalone {
+		flags = config.NewFlags(set)
 	}
-
-	// Read Git URLs from CSV file
-	repositoryURLs, err := csv.ReadRepositoryURLs(cfg.RepoCSV)
-	if err != nil {
-		log.Fatal(err)
+	if c.flags != nil {
+		c.flags(flags, set)
 	}
 
-	// create array to hold clone status
-	cloneStatus := []*repostatus.RepoStatus{}
-
-	// Clone each repository into a separate directory
-	for _, url := range repositoryURLs {
-		rs := &repostatus.RepoStatus{
-			RepoPath: url,
-		}
-		// Generate a unique directory name based on the repository URL
-		repoName := filepath.Base(url)
-		repoDir := filepath.Join(cfg.CloneDir, repoName)
-
-		errCount := 0
-		errorCode := cloneRepository(url, repoDir, rs, cfg)
-		for {
-			if errorCode != 0 {
-				errCount++
-				if errCount > MaxRetries {
-					log.Printf("Error cloning repository %s: code %d\n", url, errorCode)
-					break
-				}
-			} else {
-				break
-			}
-			switch errorCode {
-			case AuthenticationError:
-				errorCode = handleAuthenticationError(url, repoDir, cfg, rs)
-			case DirectoryExistsError:
-				errorCode = handleDirectoryExistsError(url, repoDir, cfg, rs)
-			default:
-				// Exit the loop for unknown errors
-				errCount = MaxRetries + 1
-			}
+	if err := set.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
 		}
+		os.Exit(2)
+	}
 
-		rs.IsCloned = errorCode == 0
-		if !rs.IsCloned {
-			rs.BranchCount = 0
-			rs.TagCount = 0
+	if c.standalone {
+		if err := c.run(nil, set.Args()); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
 		}
-		cloneStatus = append(cloneStatus, rs)
+		return
+	}
+
+	cfg, err := flags.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", logger.MaskSensitive(err.Error()))
+		os.Exit(1)
 	}
 
-	// Print status table
-	repostatus.PrintStatusTable(cloneStatus)
+	if err := openLog(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error initializing logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer log.Close()
 
-	// Write status to CSV file
-	if err := repostatus.WriteStatusToCSV(cloneStatus, ResultFileName); err != nil {
-		log.Printf("Error writing status to CSV file: %v\n", err)
+	log.Debug("Starting", "command", c.name, "version", Version, "config", cfg.File)
+	if err := c.run(cfg, set.Args()); err != nil {
+		fatal(err)
 	}
 }
 
-// perform git clone and return error
-func cloneRepository(url string, repoDir string, rs *repostatus.RepoStatus, cfg *config.Config) int {
-	err := git.CloneRepo(url, repoDir, rs, cfg.Username, cfg.Token)
+// openLog initializes the log and hands it to the git and metrics packages
+func openLog(cfg *config.Config) error {
+	logCfg := &logger.Config{
+		LogDir:     cfg.LogDir,
+		MaxSize:    cfg.LogMaxSize,
+		MaxBackups: cfg.LogBackups,
+		MaxAge:     time.Duration(cfg.LogMaxAge) * 24 * time.Hour,
+		Compress:   cfg.LogCompress,
+		TimeFormat: "2006-01-02",
+		Format:     cfg.LogFormat,
+		Output:     cfg.LogOutput,
+	}
+
+	// levels were validated while loading the configuration
+	logCfg.Level, _ = logger.ParseLevel(cfg.LogLevel)
+	logCfg.ConsoleLevel, _ = logger.ParseLevel(cfg.LogConsoleLevel)
+
+	var err error
+	log, err = logger.New(logCfg)
 	if err != nil {
-		return checkError(err)
+		return err
 	}
-	return 0
+
+	// Send the messages of the git and metrics packages to the same log
+	git.SetLogger(log)
+	metrics.SetLogger(log)
+	return nil
 }
 
-func checkError(err error) int {
-	if err == nil {
-		return 0
-	}
-	errStr := strings.ToLower(err.Error())
-	if strings.Contains(errStr, "auth") || strings.Contains(errStr, "authentication") || strings.Contains(errStr, "credentials") {
-		return AuthenticationError
+// fatal reports an error that stops the run on the console and in the log
+func fatal(err error) {
+	if !log.WritesToConsole() {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", logger.MaskSensitive(err.Error()))
 	}
-	if strings.Contains(errStr, "exists") || strings.Contains(errStr, "already exists") {
-		return DirectoryExistsError
-	}
-	return UnknownError
+	log.Fatal(err)
 }
 
-// handle authentication error
-func handleAuthenticationError(rurl string, repoDir string, cfg *config.Config, rs *repostatus.RepoStatus) int {
-	// If authentication failed even with a token, we stop retrying
-	if cfg.Token != "" {
-		return AuthenticationError
+// noArguments fails when a command that takes none is given arguments
+func noArguments(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(args, " "))
 	}
+	return nil
+}
 
-	return AuthenticationError
+// findCommand returns the command called name, or nil if there is none
+func findCommand(name string) *command {
+	for _, c := range commands {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
 }
 
-// handle if directory already exists, remove it and try again
-func handleDirectoryExistsError(url string, repoDir string, cfg *config.Config, rs *repostatus.RepoStatus) int {
-	// Remove the partially cloned directory
-	os.RemoveAll(repoDir)
+// isHelpFlag reports whether arg asks for help
+func isHelpFlag(arg string) bool {
+	return arg == "-h" || arg == "-help" || arg == "--help"
+}
 
-	// Clone the repository into the directory
-	return cloneRepository(url, repoDir, rs, cfg)
+// program returns the name the binary was run as
+func program() string {
+	return filepath.Base(os.Args[0])
 }
 
-// go through all the repositories in the clonedir and save the developer productivity metrics in a csv file
-func calculateMetrics(cfg *config.Config) {
-	// Read Git URLs from CSV file
-	repositoryURLs, err := csv.ReadRepositoryURLs(cfg.RepoCSV)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	// Clone each repository into a separate directory
-	for _, url := range repositoryURLs {
-		// Generate a unique directory name based on the repository URL
-		repoName := filepath.Base(url)
-		repoDir := filepath.Join(cfg.CloneDir, repoName)
-		// Clone the repository into the directory
-		errorCode := cloneRepository(url, repoDir, nil, cfg)
-		if errorCode != 0 {
-			log.Printf("Error cloning repository %s: code %d\n", url, errorCode)
-			os.Exit(1)
+// printUsage prints the list of commands
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s <command> [flags]\n\nCommands:\n", program())
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, c := range commands {
+		summary := c.summary
+		if c.name == defaultCommand {
+			summary += " (default)"
 		}
+		fmt.Fprintf(tw, "  %s\t%s\n", c.name, summary)
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\nRun '%s <command> -h' for the flags of a command.\n", program())
+	fmt.Fprintf(w, "Flags take precedence over the config file, which takes precedence over the defaults.\n")
+}
+
+// printHelp prints the usage, description and flags of the command
+func (c *command) printHelp(set *flag.FlagSet) {
+	w := set.Output()
+	fmt.Fprintf(w, "Usage: %s %s [flags]\n\n%s\n", program(), c.name, c.help)
+
+	hasFlags := false
+	set.VisitAll(func(*flag.Flag) { hasFlags = true })
+	if hasFlags {
+		fmt.Fprintf(w, "\nFlags:\n")
+		set.PrintDefaults()
 	}
 }
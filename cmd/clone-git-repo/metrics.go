@@ -0,0 +1,128 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"github.com/dmaharana/clone-git-repo/internal/pkg/config"
+	"github.com/dmaharana/clone-git-repo/internal/pkg/git"
+	"github.com/dmaharana/clone-git-repo/internal/pkg/logger"
+	"github.com/dmaharana/clone-git-repo/metrics"
+)
+
+// metricsFileName is the summary file written to the output directory; the
+// author and timeline files are named after it
+const metricsFileName = "metrics.csv"
+
+var metricsCommand = &command{
+	name:    "metrics",
+	summary: "Export developer productivity metrics of the clones",
+	help: `Analyzes the commit history of the cloned repositories and writes the
+summary, per author and per day metrics as CSV files to the output
+directory. Commits and lines per day and the velocity trend cover the last
+-window days. Only the history of HEAD is walked unless -all-branches or
+-branches is given, and -since, -until and -no-merges narrow the commits
+further. Authors are merged using each repository's .mailmap, the -alias-file
+and case-insensitive emails, and -exclude-bots leaves out the commits of
+bots. By default the repositories listed in the CSV file or discovered
+on the provider are analyzed, skipping those not cloned yet; with -scan,
+every repository found under the clone directory is.`,
+	flags: func(f *config.Flags, _ *flag.FlagSet) {
+		f.Repositories()
+		f.Credentials()
+		f.Metrics()
+	},
+	run: runMetrics,
+}
+
+// runMetrics exports the metrics of the cloned repositories
+func runMetrics(cfg *config.Config, args []string) error {
+	if err := noArguments(args); err != nil {
+		return err
+	}
+
+	repoDirs, err := metricsRepositories(cfg)
+	if err != nil {
+		return err
+	}
+	if len(repoDirs) == 0 {
+		return fmt.Errorf("no cloned repositories found in %s", cfg.CloneDir)
+	}
+
+	// the range was validated while loading the configuration
+	since, until, _ := cfg.Metrics.HistoryRange()
+	identity, err := identityOptions(cfg)
+	if err != nil {
+		return err
+	}
+	opts := &metrics.Options{
+		AllRefs:       cfg.Metrics.AllBranches,
+		Branches:      cfg.Metrics.Branches,
+		Since:         since,
+		Until:         until,
+		ExcludeMerges: !cfg.Metrics.Merges,
+		Identity:      identity,
+	}
+
+	window := metrics.LastDays(cfg.Metrics.Window)
+	output := filepath.Join(cfg.Metrics.OutputDir, metricsFileName)
+	if err := metrics.ExportMultiRepoMetrics(repoDirs, output, window, opts); err != nil {
+		return err
+	}
+	log.Info("Metrics written", "dir", cfg.Metrics.OutputDir,
+		"velocity_from", window.Start.Format("2006-01-02"), "velocity_to", window.End.Format("2006-01-02"))
+	return nil
+}
+
+// identityOptions returns how commit authors are resolved to people,
+// reading the alias file if one is configured
+func identityOptions(cfg *config.Config) (metrics.IdentityOptions, error) {
+	opts := metrics.IdentityOptions{
+		UseMailmap:  cfg.Metrics.Mailmap,
+		CaseFold:    cfg.Metrics.CaseFold,
+		BotPatterns: cfg.Metrics.BotPatterns,
+		ExcludeBots: cfg.Metrics.ExcludeBots,
+	}
+	if opts.BotPatterns == nil {
+		opts.BotPatterns = metrics.DefaultBotPatterns
+	}
+
+	if cfg.Metrics.AliasFile != "" {
+		aliases, err := metrics.LoadMailmap(cfg.Metrics.AliasFile)
+		if err != nil {
+			return opts, err
+		}
+		opts.Aliases = aliases
+	}
+	return opts, nil
+}
+
+// metricsRepositories returns the directories of the repositories to
+// analyze: every repository under the clone directory when scanning, the
+// cloned ones among those listed otherwise
+func metricsRepositories(cfg *config.Config) ([]string, error) {
+	if cfg.Metrics.Scan {
+		repoDirs, err := git.FindRepositories(cfg.CloneDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan clone directory: %w", err)
+		}
+		log.Info("Found repositories", "count", len(repoDirs), "source", cfg.CloneDir)
+		return repoDirs, nil
+	}
+
+	repositories, planned, err := loadRepositories(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var repoDirs []string
+	for i, dir := range planned {
+		if !git.IsRepository(dir) {
+			log.Warn("Repository not cloned, skipping", logger.KeyRepo, repositories[i].URL, logger.KeyDir, dir)
+			continue
+		}
+		repoDirs = append(repoDirs, dir)
+	}
+	return repoDirs, nil
+}
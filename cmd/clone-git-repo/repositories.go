@@ -0,0 +1,161 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/dmaharana/clone-git-repo/internal/pkg/config"
+	"github.com/dmaharana/clone-git-repo/internal/pkg/csv"
+	"github.com/dmaharana/clone-git-repo/internal/pkg/git"
+	"github.com/dmaharana/clone-git-repo/internal/pkg/layout"
+	"github.com/dmaharana/clone-git-repo/internal/pkg/source"
+)
+
+// loadRepositories reads the repositories from the CSV file or discovers
+// them on a hosting provider, and works out the directory of each before
+// anything touches the disk
+func loadRepositories(cfg *config.Config) ([]csv.RepoSpec, []string, error) {
+	repositories, err := listRepositories(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkProfiles(repositories, cfg); err != nil {
+		return nil, nil, err
+	}
+
+	repoDirs, err := planDirectories(repositories, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return repositories, repoDirs, nil
+}
+
+// listRepositories reads the repositories from the configured source
+func listRepositories(cfg *config.Config) ([]csv.RepoSpec, error) {
+	src, err := source.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	repositories, err := src.Repositories()
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Found repositories", "count", len(repositories), "source", cfg.Source.Type)
+	return repositories, nil
+}
+
+// checkProfiles fails if a repository names a credential profile that is
+// not configured
+func checkProfiles(repositories []csv.RepoSpec, cfg *config.Config) error {
+	var errs []error
+	for _, repository := range repositories {
+		if repository.CredentialProfile == "" || cfg.Profile(repository.CredentialProfile) != nil {
+			continue
+		}
+		err := fmt.Errorf("unknown credential profile %q for %s", repository.CredentialProfile, repository.URL)
+		if repository.Line > 0 {
+			err = &csv.RowError{File: cfg.RepoCSV, Line: repository.Line, Err: err}
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// planDirectories returns the clone directory of every repository, taken from
+// its dest column or the configured layout, failing if two would collide
+func planDirectories(repositories []csv.RepoSpec, cfg *config.Config) ([]string, error) {
+	l, err := layout.New(cfg.Layout)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make([]string, len(repositories))
+	dirs := make([]string, len(repositories))
+	for i, repository := range repositories {
+		urls[i] = repository.URL
+		dirs[i] = filepath.Clean(repository.Dest)
+		if repository.Dest == "" {
+			if dirs[i], err = l.Dir(repository.URL); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := layout.CheckCollisions(dirs, urls); err != nil {
+		return nil, err
+	}
+	for i, dir := range dirs {
+		dirs[i] = filepath.Join(cfg.CloneDir, dir)
+	}
+
+	return dirs, nil
+}
+
+// repositoryOptions returns the git options for a repository
+func repositoryOptions(spec csv.RepoSpec, cfg *config.Config) (*git.Options, error) {
+	auth := cfg.Auth
+	if spec.Auth != "" {
+		auth = spec.Auth
+	}
+
+	username, token, err := cfg.Credentials(spec.URL, spec.CredentialProfile)
+	if err != nil {
+		return nil, err
+	}
+
+	// progress lines are only readable when one repository is processed
+	// at a time
+	var progress io.Writer
+	if cfg.Concurrency == 1 {
+		progress = os.Stdout
+	}
+
+	return &git.Options{
+		Progress: progress,
+		Username: username,
+		Token:    token,
+		Branch:   spec.Branch,
+		Depth:    spec.Depth,
+		UseSSH:   auth == config.AuthSSH,
+		SSH: &git.SSHOptions{
+			KeyFile:       cfg.SSH.KeyFile,
+			Passphrase:    cfg.SSH.Passphrase,
+			UseAgent:      cfg.SSH.UseAgent,
+			KnownHosts:    cfg.SSH.KnownHosts,
+			HostKeyPolicy: cfg.SSH.HostKeyPolicy,
+		},
+	}, nil
+}
+
+// parallel calls fn for every index below count using a bounded pool of
+// workers
+func parallel(count, workers int, fn func(i int)) {
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > count {
+		workers = count
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < count; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/dmaharana/clone-git-repo/internal/pkg/config"
+	"github.com/dmaharana/clone-git-repo/internal/pkg/git"
+	"github.com/dmaharana/clone-git-repo/internal/pkg/logger"
+	"github.com/olekukonko/tablewriter"
+)
+
+var statusCommand = &command{
+	name:    "status",
+	summary: "Show the state of the local clones",
+	help: `Shows, for every repository, whether it has been cloned into the clone
+directory, whether it is a clone or a mirror, the checked out branch, the
+number of branches and tags, and the date of the last commit. The remotes
+are not contacted.`,
+	flags: func(f *config.Flags, _ *flag.FlagSet) {
+		f.Repositories()
+		f.Credentials()
+	},
+	run: runStatus,
+}
+
+// runStatus prints the state of the clone directory of every repository
+func runStatus(cfg *config.Config, args []string) error {
+	if err := noArguments(args); err != nil {
+		return err
+	}
+
+	repositories, repoDirs, err := loadRepositories(cfg)
+	if err != nil {
+		return err
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Repository", "Directory", "State", "Head", "Branches", "Tags", "Last Commit"})
+
+	missing := 0
+	for i, repository := range repositories {
+		state, err := git.Inspect(repoDirs[i])
+		if err != nil {
+			log.Warn("Failed to inspect repository", logger.KeyRepo, repository.URL, logger.KeyDir, repoDirs[i], logger.KeyError, err)
+			table.Append([]string{logger.MaskSensitive(repository.URL), repoDirs[i], "error", "", "", "", ""})
+			continue
+		}
+		if !state.IsRepository {
+			missing++
+		}
+
+		lastCommit := ""
+		if !state.LastCommit.IsZero() {
+			lastCommit = state.LastCommit.Format("2006-01-02 15:04")
+		}
+		table.Append([]string{
+			logger.MaskSensitive(repository.URL),
+			repoDirs[i],
+			stateName(state),
+			state.Head,
+			fmt.Sprintf("%d", state.Branches),
+			fmt.Sprintf("%d", state.Tags),
+			lastCommit,
+		})
+	}
+
+	table.Render()
+	log.Info("Checked repositories", "count", len(repositories), "missing", missing)
+	return nil
+}
+
+// stateName describes a clone directory in the status table
+func stateName(state *git.LocalState) string {
+	switch {
+	case !state.Exists:
+		return "missing"
+	case !state.IsRepository:
+		return "not a repository"
+	case state.Bare:
+		return config.ModeMirror
+	default:
+		return "cloned"
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/dmaharana/clone-git-repo/internal/pkg/config"
+	"github.com/dmaharana/clone-git-repo/internal/pkg/git"
+	"github.com/dmaharana/clone-git-repo/internal/pkg/logger"
+	"github.com/olekukonko/tablewriter"
+)
+
+// verifyOffline skips contacting the remotes
+var verifyOffline bool
+
+var verifyCommand = &command{
+	name:    "verify",
+	summary: "Check the configuration and access to every repository",
+	help: `Checks the configuration, reads the repository list, resolves the
+credentials and clone directory of every repository and, unless -offline is
+given, lists the refs of every remote to make sure it can be cloned. Nothing
+is written to the clone directory. Exits with an error if any check fails.`,
+	flags: func(f *config.Flags, set *flag.FlagSet) {
+		f.Repositories()
+		f.Credentials()
+		f.Concurrency()
+		f.Clone()
+		set.BoolVar(&verifyOffline, "offline", false, "Only check the configuration and repository list, not the remotes")
+	},
+	run: runVerify,
+}
+
+// verifyResult is the outcome of checking a single remote
+type verifyResult struct {
+	refs int
+	err  error
+}
+
+// runVerify checks that every repository can be cloned
+func runVerify(cfg *config.Config, args []string) error {
+	if err := noArguments(args); err != nil {
+		return err
+	}
+
+	if cfg.File != "" {
+		log.Info("Configuration is valid", "file", cfg.File)
+	} else {
+		log.Info("No config file, using the defaults", "file", config.DefaultConfigFile)
+	}
+
+	repositories, _, err := loadRepositories(cfg)
+	if err != nil {
+		return err
+	}
+	if verifyOffline {
+		log.Info("Repository list is valid", "count", len(repositories))
+		return nil
+	}
+
+	results := make([]verifyResult, len(repositories))
+	parallel(len(repositories), cfg.Concurrency, func(i int) {
+		spec := repositories[i]
+		if spec.Mode == config.ModeSkip {
+			return
+		}
+		opts, err := repositoryOptions(spec, cfg)
+		if err == nil {
+			results[i].refs, err = git.VerifyRemote(spec.URL, opts)
+		}
+		results[i].err = err
+		if err != nil {
+			log.Warn("Repository is not accessible", logger.KeyRepo, spec.URL,
+				logger.KeyErrorClass, git.Classify(err), logger.KeyError, err)
+		}
+	})
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Repository", "Auth", "Refs", "Result", "Error"})
+
+	failed := 0
+	for i, spec := range repositories {
+		auth := cfg.Auth
+		if spec.Auth != "" {
+			auth = spec.Auth
+		}
+
+		result, refs, message := "ok", fmt.Sprintf("%d", results[i].refs), ""
+		switch {
+		case spec.Mode == config.ModeSkip:
+			result, refs = config.ModeSkip, ""
+		case results[i].err != nil:
+			failed++
+			result, refs = string(git.Classify(results[i].err)), ""
+			message = results[i].err.Error()
+		}
+		table.Append([]string{
+			logger.MaskSensitive(spec.URL),
+			auth,
+			refs,
+			result,
+			logger.MaskSensitive(message),
+		})
+	}
+	table.Render()
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d repositories failed verification", failed, len(repositories))
+	}
+	log.Info("All repositories are accessible", "count", len(repositories))
+	return nil
+}
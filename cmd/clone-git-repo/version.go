@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/dmaharana/clone-git-repo/internal/pkg/config"
+)
+
+var versionCommand = &command{
+	name:       "version",
+	summary:    "Print the version",
+	help:       "Prints the version, build time and git commit the binary was built from.",
+	standalone: true,
+	run:        runVersion,
+}
+
+// runVersion prints the build information
+func runVersion(_ *config.Config, args []string) error {
+	if err := noArguments(args); err != nil {
+		return err
+	}
+
+	version := Version
+	if version == "" {
+		version = "dev"
+	}
+	fmt.Printf("Version: %s\n", version)
+	if BuildTime != "" {
+		fmt.Printf("Build Time: %s\n", BuildTime)
+		fmt.Printf("Git Commit: %s\n", GitCommit)
+		fmt.Printf("Git Branch: %s\n", GitBranch)
+	}
+	fmt.Printf("Go Version: %s\n", runtime.Version())
+	return nil
+}
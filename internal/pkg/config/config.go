@@ -1,82 +1,635 @@
 package config
 
 import (
-	"flag"
-	"log"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"strings"
+	"time"
 
+	"github.com/dmaharana/clone-git-repo/internal/pkg/credentials"
+	"github.com/dmaharana/clone-git-repo/internal/pkg/git"
+	"github.com/dmaharana/clone-git-repo/internal/pkg/logger"
+	"github.com/dmaharana/clone-git-repo/internal/pkg/paths"
+	"github.com/dmaharana/clone-git-repo/internal/pkg/retry"
 	"gopkg.in/ini.v1"
 )
 
 // Config holds the application configuration
 type Config struct {
-	RepoCSV    string
-	CloneDir   string
-	Username   string
-	Token      string
-	LogDir     string
-	LogMaxSize int64
+	File        string // configuration file read, empty when running on defaults
+	RepoCSV     string
+	CloneDir    string
+	Username    string
+	Token       string
+	LogDir      string
+	LogMaxSize  int64
+	LogBackups  int    // rotated log files to keep, 0 to keep all
+	LogMaxAge   int    // days to keep rotated log files, 0 to keep them
+	LogCompress bool   // gzip rotated log files
+	LogLevel    string // debug, info, warn or error, for the log file
+	LogFormat   string // text or json
+	LogOutput   string // file, stderr or both
+	// LogConsoleLevel is the level of the console output, LogLevel if not set
+	LogConsoleLevel string
+	Concurrency     int
+	Sync            bool
+	Mode            string
+	Layout          string
+	Retry           *retry.Policy
+	Source          SourceConfig
+	Auth            string // https or ssh, how SSH URLs are cloned
+	SSH             SSHConfig
+	Profiles        []CredentialProfile // named credentials from [credentials.NAME] sections
+	TokenFile       string              // file holding the default token
+	TokenEnv        string              // environment variable holding the default token
+	UsernameEnv     string              // environment variable holding the default username
+	// CredentialSources lists where credentials are looked up, in order
+	CredentialSources []string
+	// MaskPatterns are regular expressions masked in logs and reports on top
+	// of the built-in rules
+	MaskPatterns []string
+	Metrics      MetricsConfig
+}
+
+// MetricsConfig holds the settings of the metrics export
+type MetricsConfig struct {
+	OutputDir string // directory the metrics CSV files are written to
+	Scan      bool   // analyze every repository under the clone directory, not just the listed ones
+	Window    int    // days up to now over which the velocity is computed
+	// AllBranches walks every branch and tag instead of HEAD
+	AllBranches bool
+	Branches    []string // walk these branches instead of HEAD
+	Since       string   // skip commits authored before this date
+	Until       string   // skip commits authored after this date
+	Merges      bool     // include merge commits
+	Mailmap     bool     // honor the .mailmap of each repository
+	AliasFile   string   // mailmap applied to every repository
+	CaseFold    bool     // compare author emails case-insensitively
+	// BotPatterns match the names or emails of bots, nil for the defaults
+	BotPatterns []string
+	ExcludeBots bool // leave the commits of bots out of the metrics
+}
+
+// HistoryRange returns the dates between which commits are analyzed, zero
+// when not set. Dates are YYYY-MM-DD in local time or RFC 3339; a date
+// without a time includes the whole day in Until.
+func (m MetricsConfig) HistoryRange() (time.Time, time.Time, error) {
+	since, err := parseDate(m.Since, false)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid since date: %w", err)
+	}
+	until, err := parseDate(m.Until, true)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid until date: %w", err)
+	}
+	if !since.IsZero() && !until.IsZero() && until.Before(since) {
+		return time.Time{}, time.Time{}, fmt.Errorf("until date %s is before since date %s", m.Until, m.Since)
+	}
+	return since, until, nil
+}
+
+// parseDate parses a YYYY-MM-DD or RFC 3339 date, returning the end of the
+// day for a plain date when endOfDay is set
+func parseDate(s string, endOfDay bool) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not a YYYY-MM-DD or RFC 3339 date", s)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return t, nil
+}
+
+// CredentialProfile holds the credentials used for the repositories of one
+// host or URL prefix
+type CredentialProfile struct {
+	Name        string
+	Host        string // matches repository URLs on this host or its subdomains
+	URLPrefix   string // matches repository URLs starting with this prefix
+	Username    string
+	Token       string
+	TokenFile   string // file holding the token
+	TokenEnv    string // environment variable holding the token
+	UsernameEnv string // environment variable holding the username
+}
+
+// SSHConfig holds the settings for cloning over SSH
+type SSHConfig struct {
+	KeyFile       string // private key file
+	Passphrase    string // passphrase of the private key
+	UseAgent      bool   // use ssh-agent when no key file is set
+	KnownHosts    string // known_hosts file, empty for ~/.ssh/known_hosts
+	HostKeyPolicy string // strict, accept-new or insecure
+}
+
+// SourceConfig selects where the list of repositories comes from
+type SourceConfig struct {
+	Type            string   // csv (default) or the name of a hosting provider
+	BaseURL         string   // API base URL, empty for the provider's public service
+	Owner           string   // organization, group or user to discover
+	OwnerType       string   // org or user, for providers that distinguish them
+	Protocol        string   // clone URL protocol: https (default) or ssh
+	IncludeArchived bool     // also clone archived repositories
+	IncludeForks    bool     // also clone forks
+	Visibility      string   // all (default), public, private or internal
+	Topics          []string // only repositories with at least one of these topics
+	Include         []string // only repositories whose path matches one of these globs
+	Exclude         []string // skip repositories whose path matches one of these globs
+	MirrorHierarchy bool     // clone into directories mirroring the provider's group hierarchy
+	Profile         string   // credential profile used for the provider API, matched by base URL when empty
 }
 
 const (
 	DefaultCSVFile    = "repositories.csv"
 	DefaultCloneDir   = "clonedir"
 	DefaultConfigFile = "config.ini"
+
+	// DefaultConcurrency is the number of repositories cloned in parallel
+	DefaultConcurrency = 4
+
+	// DefaultSource reads repositories from the CSV file
+	DefaultSource = "csv"
+
+	// DefaultLogBackups is the number of rotated log files kept, none are
+	// removed by default
+	DefaultLogBackups = 0
+
+	// DefaultMetricsDir receives the metrics CSV files
+	DefaultMetricsDir = "metrics"
+
+	// DefaultVelocityWindow is the number of days the velocity is computed over
+	DefaultVelocityWindow = 30
+)
+
+// Clone modes, selectable per run or per repository
+const (
+	ModeClone  = "clone"  // regular clone with a worktree and all branches checked out
+	ModeMirror = "mirror" // bare mirror of every ref, for backups
+	ModeSkip   = "skip"   // leave the repository alone, per repository only
+)
+
+// Authentication methods for SSH URLs, selectable per run or per repository
+const (
+	AuthHTTPS = "https" // convert SSH URLs to HTTPS when a token is set
+	AuthSSH   = "ssh"   // clone SSH URLs over SSH with a key or ssh-agent
+)
+
+// Allowed values of the [source] settings
+var (
+	sourceProtocols    = []string{"https", "ssh"}
+	sourceVisibilities = []string{"all", "public", "private", "internal"}
+	sourceOwnerTypes   = []string{"org", "user"}
 )
 
-// ParseFlags parses command line flags and config file, returns a Config struct
-func ParseFlags() *Config {
-	cfg := &Config{}
-	var configFile string
+// ValidAuth reports whether auth is a known authentication method
+func ValidAuth(auth string) bool {
+	return auth == AuthHTTPS || auth == AuthSSH
+}
 
-	// Only parse the config file path from command line
-	flag.StringVar(&configFile, "c", DefaultConfigFile, "Path to config file")
-	flag.Parse()
+// ValidMode reports whether mode is a known clone mode
+func ValidMode(mode string) bool {
+	return mode == ModeClone || mode == ModeMirror
+}
 
-	// Load config file
-	iniFile, err := ini.Load(configFile)
+// Load reads the configuration file at path, failing if it does not exist
+func Load(path string) (*Config, error) {
+	cfg, err := read(path, true)
 	if err != nil {
-		log.Printf("Warning: Could not load config file: %v\n", err)
-		log.Printf("Using command line arguments instead\n")
-		return parseCommandLineArgs()
+		return nil, err
+	}
+	if err := cfg.finish(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// read reads the configuration file at path, using the defaults for
+// anything it does not set. A missing file yields the defaults unless
+// required is set.
+func read(path string, required bool) (*Config, error) {
+	cfg := &Config{File: path}
+
+	iniFile, err := ini.Load(path)
+	switch {
+	case err == nil:
+	case !required && errors.Is(err, fs.ErrNotExist):
+		cfg.File = ""
+		iniFile = ini.Empty()
+	default:
+		return nil, fmt.Errorf("failed to load config file: %w", err)
 	}
 
-	// Read from config file
-	credentials := iniFile.Section("credentials")
-	cfg.Username = credentials.Key("username").String()
-	cfg.Token = credentials.Key("token").String()
+	cfg.parse(iniFile)
+	return cfg, nil
+}
+
+// parse reads the settings of the configuration file
+func (cfg *Config) parse(iniFile *ini.File) {
+	creds := iniFile.Section("credentials")
+	cfg.Username = creds.Key("username").String()
+	cfg.Token = creds.Key("token").String()
+	cfg.TokenFile = creds.Key("token_file").String()
+	cfg.TokenEnv = creds.Key("token_env").String()
+	cfg.UsernameEnv = creds.Key("username_env").String()
+	cfg.CredentialSources = parseCredentialSources(creds)
+	cfg.Profiles = parseProfiles(iniFile)
 
 	paths := iniFile.Section("paths")
 	cfg.RepoCSV = paths.Key("csv_file").MustString(DefaultCSVFile)
 	cfg.CloneDir = paths.Key("clone_dir").MustString(DefaultCloneDir)
+	cfg.Concurrency = paths.Key("concurrency").MustInt(DefaultConcurrency)
+	cfg.Layout = paths.Key("layout").String()
+
+	clone := iniFile.Section("clone")
+	cfg.Sync = clone.Key("sync").MustBool(false)
+	cfg.Mode = clone.Key("mode").MustString(ModeClone)
+	cfg.Auth = clone.Key("auth").MustString(AuthHTTPS)
+
+	cfg.Retry = parseRetryPolicy(iniFile.Section("retry"))
+	cfg.Source = parseSource(iniFile.Section("source"))
+	cfg.SSH = parseSSH(iniFile.Section("ssh"))
 
 	logging := iniFile.Section("logging")
 	cfg.LogDir = logging.Key("log_dir").MustString("logs")
 	cfg.LogMaxSize = logging.Key("log_max_size").MustInt64(10 * 1024 * 1024)
+	cfg.LogBackups = logging.Key("log_max_backups").MustInt(DefaultLogBackups)
+	cfg.LogMaxAge = logging.Key("log_max_age").MustInt(0)
+	cfg.LogCompress = logging.Key("log_compress").MustBool(false)
+	cfg.LogLevel = logging.Key("level").MustString("info")
+	cfg.LogConsoleLevel = logging.Key("console_level").String()
+	cfg.LogFormat = logging.Key("format").MustString(logger.FormatText)
+	cfg.LogOutput = logging.Key("output").MustString(logger.OutputBoth)
+
+	cfg.MaskPatterns = parseMaskPatterns(iniFile.Section("masking"))
+
+	metrics := iniFile.Section("metrics")
+	cfg.Metrics.OutputDir = metrics.Key("output_dir").MustString(DefaultMetricsDir)
+	cfg.Metrics.Scan = metrics.Key("scan_clone_dir").MustBool(false)
+	cfg.Metrics.Window = metrics.Key("velocity_window").MustInt(DefaultVelocityWindow)
+	cfg.Metrics.AllBranches = metrics.Key("all_branches").MustBool(false)
+	cfg.Metrics.Branches = metrics.Key("branches").Strings(",")
+	cfg.Metrics.Since = metrics.Key("since").String()
+	cfg.Metrics.Until = metrics.Key("until").String()
+	cfg.Metrics.Merges = metrics.Key("merges").MustBool(true)
+	cfg.Metrics.Mailmap = metrics.Key("mailmap").MustBool(true)
+	cfg.Metrics.AliasFile = metrics.Key("alias_file").String()
+	cfg.Metrics.CaseFold = metrics.Key("case_fold").MustBool(true)
+	if metrics.HasKey("bot_patterns") {
+		cfg.Metrics.BotPatterns = []string{}
+		for _, pattern := range metrics.Key("bot_patterns").Strings(",") {
+			if pattern != "" {
+				cfg.Metrics.BotPatterns = append(cfg.Metrics.BotPatterns, pattern)
+			}
+		}
+	}
+	cfg.Metrics.ExcludeBots = metrics.Key("exclude_bots").MustBool(false)
+}
+
+// finish normalizes and validates the settings once the command line has
+// been applied, then registers the masking rules and secrets
+func (cfg *Config) finish() error {
+	cfg.Mode = lower(cfg.Mode)
+	cfg.Auth = lower(cfg.Auth)
+	cfg.Source.Type = lower(cfg.Source.Type)
+	cfg.LogLevel = lower(cfg.LogLevel)
+	cfg.LogFormat = lower(cfg.LogFormat)
+	cfg.LogOutput = lower(cfg.LogOutput)
+	// the console follows the log level unless console_level is set
+	cfg.LogConsoleLevel = lower(cfg.LogConsoleLevel)
+	if cfg.LogConsoleLevel == "" {
+		cfg.LogConsoleLevel = cfg.LogLevel
+	}
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 1
+	}
+
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+
+	for _, pattern := range cfg.MaskPatterns {
+		if err := logger.AddMaskingRule(pattern); err != nil {
+			return err
+		}
+	}
+	registerSecrets(cfg)
+	return nil
+}
+
+// validate reports every unknown setting value
+func (cfg *Config) validate() error {
+	var errs []error
+	if !ValidMode(cfg.Mode) {
+		errs = append(errs, fmt.Errorf("unknown clone mode %q, expected %s or %s", cfg.Mode, ModeClone, ModeMirror))
+	}
+	if cfg.Metrics.Window < 1 {
+		errs = append(errs, fmt.Errorf("invalid velocity window %d, expected a number of days", cfg.Metrics.Window))
+	}
+	if _, _, err := cfg.Metrics.HistoryRange(); err != nil {
+		errs = append(errs, err)
+	}
+	if cfg.Metrics.AllBranches && len(cfg.Metrics.Branches) > 0 {
+		errs = append(errs, fmt.Errorf("all_branches and branches cannot be used together"))
+	}
+	if !ValidAuth(cfg.Auth) {
+		errs = append(errs, fmt.Errorf("unknown auth method %q, expected %s or %s", cfg.Auth, AuthHTTPS, AuthSSH))
+	}
+	if _, err := logger.ParseLevel(cfg.LogLevel); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := logger.ParseLevel(cfg.LogConsoleLevel); err != nil && cfg.LogConsoleLevel != cfg.LogLevel {
+		errs = append(errs, err)
+	}
+	if !logger.ValidFormat(cfg.LogFormat) {
+		errs = append(errs, fmt.Errorf("unknown log format %q, expected %s or %s", cfg.LogFormat, logger.FormatText, logger.FormatJSON))
+	}
+	if !logger.ValidOutput(cfg.LogOutput) {
+		errs = append(errs, fmt.Errorf("unknown log output %q, expected %s, %s or %s",
+			cfg.LogOutput, logger.OutputFile, logger.OutputStderr, logger.OutputBoth))
+	}
+	if !git.ValidHostKeyPolicy(cfg.SSH.HostKeyPolicy) {
+		errs = append(errs, fmt.Errorf("unknown host_key_policy %q, expected %s, %s or %s",
+			cfg.SSH.HostKeyPolicy, git.HostKeyStrict, git.HostKeyAcceptNew, git.HostKeyInsecure))
+	}
+	if !oneOf(cfg.Source.Protocol, sourceProtocols) {
+		errs = append(errs, fmt.Errorf("unknown source protocol %q, expected %s",
+			cfg.Source.Protocol, strings.Join(sourceProtocols, ", ")))
+	}
+	if !oneOf(cfg.Source.Visibility, sourceVisibilities) {
+		errs = append(errs, fmt.Errorf("unknown source visibility %q, expected %s",
+			cfg.Source.Visibility, strings.Join(sourceVisibilities, ", ")))
+	}
+	if !oneOf(cfg.Source.OwnerType, sourceOwnerTypes) {
+		errs = append(errs, fmt.Errorf("unknown source owner_type %q, expected %s",
+			cfg.Source.OwnerType, strings.Join(sourceOwnerTypes, ", ")))
+	}
+	for _, name := range cfg.CredentialSources {
+		if !validCredentialSource(name) {
+			errs = append(errs, fmt.Errorf("unknown credential source %q, expected %s",
+				name, strings.Join(credentials.DefaultSources, ", ")))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// parseRetryPolicy reads the retry policy, falling back to the defaults for
+// anything not configured
+func parseRetryPolicy(section *ini.Section) *retry.Policy {
+	policy := retry.DefaultPolicy()
+
+	policy.MaxAttempts = section.Key("max_attempts").MustInt(policy.MaxAttempts)
+	policy.BaseDelay = section.Key("base_delay").MustDuration(policy.BaseDelay)
+	policy.MaxDelay = section.Key("max_delay").MustDuration(policy.MaxDelay)
+	policy.Jitter = section.Key("jitter").MustFloat64(policy.Jitter)
+	if section.HasKey("retryable") {
+		policy.Retryable = nil
+		for _, class := range section.Key("retryable").Strings(",") {
+			policy.Retryable = append(policy.Retryable, strings.ToLower(class))
+		}
+	}
+
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+
+	return policy
+}
+
+// parseProfiles reads the [credentials.NAME] sections
+func parseProfiles(iniFile *ini.File) []CredentialProfile {
+	var profiles []CredentialProfile
+	for _, section := range iniFile.Sections() {
+		name, ok := strings.CutPrefix(section.Name(), "credentials.")
+		if !ok || name == "" {
+			continue
+		}
+		profiles = append(profiles, CredentialProfile{
+			Name:        name,
+			Host:        strings.ToLower(section.Key("host").String()),
+			URLPrefix:   section.Key("url_prefix").String(),
+			Username:    section.Key("username").String(),
+			Token:       section.Key("token").String(),
+			TokenFile:   section.Key("token_file").String(),
+			TokenEnv:    section.Key("token_env").String(),
+			UsernameEnv: section.Key("username_env").String(),
+		})
+	}
+	return profiles
+}
+
+// Profile returns the credential profile called name, or nil if there is none
+func (c *Config) Profile(name string) *CredentialProfile {
+	for i := range c.Profiles {
+		if strings.EqualFold(c.Profiles[i].Name, name) {
+			return &c.Profiles[i]
+		}
+	}
+	return nil
+}
+
+// Credentials returns the username and token for a repository or API URL.
+// A named profile is used as is; otherwise the profile with the longest
+// matching url_prefix wins, then the profile with the most specific matching
+// host, and finally the default [credentials] section. The credential
+// sources are then tried in order for that profile. Empty credentials mean
+// anonymous access.
+func (c *Config) Credentials(repoURL, profile string) (string, string, error) {
+	p, err := c.matchProfile(repoURL, profile)
+	if err != nil {
+		return "", "", err
+	}
+
+	cred, _, err := c.credentialChain(p).Lookup(repoURL)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to look up credentials for %s: %w", repoURL, err)
+	}
+	// tokens from the environment, files or helpers are only known now
+	logger.AddSecrets(cred.Token)
+	if cred.Username == "" {
+		cred.Username = p.Username
+	}
+	return cred.Username, cred.Token, nil
+}
+
+// matchProfile returns the credential profile for a URL, the default
+// credentials having an empty name
+func (c *Config) matchProfile(repoURL, profile string) (*CredentialProfile, error) {
+	if profile != "" {
+		p := c.Profile(profile)
+		if p == nil {
+			return nil, fmt.Errorf("unknown credential profile %q", profile)
+		}
+		return p, nil
+	}
 
-	// Validate required fields
-	if cfg.Username == "" || cfg.Token == "" {
-		log.Fatal("Username and Token are required in config file")
+	var match *CredentialProfile
+	for i := range c.Profiles {
+		p := &c.Profiles[i]
+		if p.URLPrefix != "" && strings.HasPrefix(repoURL, p.URLPrefix) &&
+			(match == nil || len(p.URLPrefix) > len(match.URLPrefix)) {
+			match = p
+		}
+	}
+	if match == nil {
+		host := urlHost(repoURL)
+		for i := range c.Profiles {
+			p := &c.Profiles[i]
+			if p.Host != "" && host != "" && (host == p.Host || strings.HasSuffix(host, "."+p.Host)) &&
+				(match == nil || len(p.Host) > len(match.Host)) {
+				match = p
+			}
+		}
+	}
+	if match != nil {
+		return match, nil
 	}
 
-	return cfg
+	return &CredentialProfile{
+		Username:    c.Username,
+		Token:       c.Token,
+		TokenFile:   c.TokenFile,
+		TokenEnv:    c.TokenEnv,
+		UsernameEnv: c.UsernameEnv,
+	}, nil
 }
 
-// parseCommandLineArgs parses command line arguments as fallback
-func parseCommandLineArgs() *Config {
-	cfg := &Config{}
+// credentialChain builds the lookup chain of a profile from the configured
+// sources
+func (c *Config) credentialChain(p *CredentialProfile) credentials.Chain {
+	sources := c.CredentialSources
+	if sources == nil {
+		sources = credentials.DefaultSources
+	}
+
+	var chain credentials.Chain
+	for _, name := range sources {
+		switch name {
+		case credentials.SourceConfig:
+			chain = append(chain, credentials.Static{Username: p.Username, Token: p.Token})
+		case credentials.SourceFile:
+			chain = append(chain, credentials.TokenFile{Path: p.TokenFile, Username: p.Username})
+		case credentials.SourceEnv:
+			env := credentials.Env{
+				UsernameVar: credentials.EnvVar(p.Name, "username"),
+				TokenVar:    credentials.EnvVar(p.Name, "token"),
+				Username:    p.Username,
+			}
+			if p.UsernameEnv != "" {
+				env.UsernameVar = p.UsernameEnv
+			}
+			if p.TokenEnv != "" {
+				env.TokenVar = p.TokenEnv
+			}
+			chain = append(chain, env)
+		case credentials.SourceNetrc:
+			chain = append(chain, credentials.Netrc{})
+		case credentials.SourceGit:
+			chain = append(chain, credentials.GitHelper{Username: p.Username})
+		}
+	}
+	return chain
+}
 
-	flag.StringVar(&cfg.RepoCSV, "f", DefaultCSVFile, "CSV file")
-	flag.StringVar(&cfg.CloneDir, "d", DefaultCloneDir, "Clone directory")
-	flag.StringVar(&cfg.Username, "u", "", "Username")
-	flag.StringVar(&cfg.Token, "t", "", "Token")
-	flag.StringVar(&cfg.LogDir, "logdir", "logs", "Log directory")
-	flag.Int64Var(&cfg.LogMaxSize, "logsize", 10*1024*1024, "Maximum log file size in bytes")
+// parseCredentialSources reads the credential lookup order
+func parseCredentialSources(section *ini.Section) []string {
+	if !section.HasKey("sources") {
+		return credentials.DefaultSources
+	}
 
-	flag.Parse()
+	sources := []string{}
+	for _, name := range section.Key("sources").Strings(",") {
+		sources = append(sources, strings.ToLower(name))
+	}
+	return sources
+}
 
-	if cfg.Username == "" || cfg.Token == "" {
-		log.Fatal("Username and Token are required")
+// validCredentialSource reports whether name is a known credential source
+func validCredentialSource(name string) bool {
+	switch name {
+	case credentials.SourceConfig, credentials.SourceFile, credentials.SourceEnv,
+		credentials.SourceNetrc, credentials.SourceGit:
+		return true
 	}
+	return false
+}
 
-	return cfg
+// oneOf reports whether value is one of the allowed values
+func oneOf(value string, allowed []string) bool {
+	for _, a := range allowed {
+		if value == a {
+			return true
+		}
+	}
+	return false
+}
+
+// urlHost returns the lower-cased host of a repository or API URL
+func urlHost(rawURL string) string {
+	if strings.Contains(rawURL, "://") {
+		if u, err := url.Parse(rawURL); err == nil {
+			return strings.ToLower(u.Hostname())
+		}
+	}
+	if u, err := paths.ParseURL(rawURL); err == nil {
+		return u.Host
+	}
+	return ""
+}
+
+// parseMaskPatterns reads the extra masking rules, one regular expression
+// per key
+func parseMaskPatterns(section *ini.Section) []string {
+	var patterns []string
+	for _, key := range section.Keys() {
+		patterns = append(patterns, key.String())
+	}
+	return patterns
+}
+
+// registerSecrets has every credential loaded into the configuration masked
+// wherever it appears
+func registerSecrets(cfg *Config) {
+	logger.AddSecrets(cfg.Token, cfg.SSH.Passphrase)
+	for _, p := range cfg.Profiles {
+		logger.AddSecrets(p.Token)
+	}
+}
+
+// parseSSH reads the SSH settings
+func parseSSH(section *ini.Section) SSHConfig {
+	return SSHConfig{
+		KeyFile:       section.Key("key_file").String(),
+		Passphrase:    section.Key("passphrase").String(),
+		UseAgent:      section.Key("use_agent").MustBool(true),
+		KnownHosts:    section.Key("known_hosts").String(),
+		HostKeyPolicy: strings.ToLower(section.Key("host_key_policy").MustString("strict")),
+	}
+}
+
+// parseSource reads the repository source
+func parseSource(section *ini.Section) SourceConfig {
+	return SourceConfig{
+		Type:            strings.ToLower(section.Key("type").MustString(DefaultSource)),
+		BaseURL:         strings.TrimSuffix(section.Key("base_url").String(), "/"),
+		Owner:           section.Key("owner").String(),
+		OwnerType:       strings.ToLower(section.Key("owner_type").MustString("org")),
+		Protocol:        strings.ToLower(section.Key("protocol").MustString("https")),
+		IncludeArchived: section.Key("include_archived").MustBool(false),
+		IncludeForks:    section.Key("include_forks").MustBool(false),
+		Visibility:      strings.ToLower(section.Key("visibility").MustString("all")),
+		Topics:          section.Key("topics").Strings(","),
+		Include:         section.Key("include").Strings(","),
+		Exclude:         section.Key("exclude").Strings(","),
+		MirrorHierarchy: section.Key("mirror_hierarchy").MustBool(true),
+		Profile:         section.Key("credential_profile").String(),
+	}
 }
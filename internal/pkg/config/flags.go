@@ -0,0 +1,177 @@
+package config
+
+import (
+	"flag"
+	"strconv"
+	"strings"
+)
+
+// Flags holds the command line flags of a command. Only the flags given on
+// the command line override the configuration, so flags take precedence over
+// the configuration file, which takes precedence over the defaults.
+type Flags struct {
+	set        *flag.FlagSet
+	configFile string
+	overrides  map[string]func(*Config)
+}
+
+// NewFlags registers the flags shared by every command that reads the
+// configuration on set
+func NewFlags(set *flag.FlagSet) *Flags {
+	f := &Flags{set: set, overrides: make(map[string]func(*Config))}
+
+	set.StringVar(&f.configFile, "c", DefaultConfigFile, "Path to config `file`")
+	f.String("log-level", "Log `level`: debug, info, warn or error", func(cfg *Config, v string) { cfg.LogLevel = v })
+	f.String("log-format", "Log `format`: text or json", func(cfg *Config, v string) { cfg.LogFormat = v })
+	f.String("log-output", "Log `output`: file, stderr or both", func(cfg *Config, v string) { cfg.LogOutput = v })
+	f.String("logdir", "Log `directory`", func(cfg *Config, v string) { cfg.LogDir = v })
+	f.Int64("logsize", "Maximum log file size in `bytes`", func(cfg *Config, v int64) { cfg.LogMaxSize = v })
+	f.Int("logbackups", "Keep `n` rotated log files", func(cfg *Config, v int) { cfg.LogBackups = v })
+
+	return f
+}
+
+// Repositories registers the flags selecting the repositories and where
+// they are cloned
+func (f *Flags) Repositories() {
+	f.String("f", "CSV `file` listing the repositories", func(cfg *Config, v string) { cfg.RepoCSV = v })
+	f.String("d", "Clone `directory`", func(cfg *Config, v string) { cfg.CloneDir = v })
+	f.String("layout", "Clone directory `layout`: flat, owner, host or a template such as {host}/{owner}/{repo}",
+		func(cfg *Config, v string) { cfg.Layout = v })
+	f.String("source", "Repository `source`: csv or a hosting provider such as github",
+		func(cfg *Config, v string) { cfg.Source.Type = v })
+	f.String("owner", "Discover the repositories of `owner`, an organization, group or user",
+		func(cfg *Config, v string) { cfg.Source.Owner = v })
+}
+
+// Credentials registers the flags setting the default credentials
+func (f *Flags) Credentials() {
+	f.String("u", "Default `username`", func(cfg *Config, v string) { cfg.Username = v })
+	f.String("t", "Default `token`", func(cfg *Config, v string) { cfg.Token = v })
+	f.String("token-file", "Read the default token from `file`", func(cfg *Config, v string) { cfg.TokenFile = v })
+}
+
+// Concurrency registers the flag setting the number of parallel workers
+func (f *Flags) Concurrency() {
+	f.Int("j", "Process `n` repositories in parallel", func(cfg *Config, v int) { cfg.Concurrency = v })
+}
+
+// Clone registers the flags controlling how repositories are cloned
+func (f *Flags) Clone() {
+	f.String("mode", "Clone `mode`: clone or mirror", func(cfg *Config, v string) { cfg.Mode = v })
+	f.String("auth", "Authentication `method` for SSH URLs: https or ssh", func(cfg *Config, v string) { cfg.Auth = v })
+}
+
+// Sync registers the flag fetching into existing clones
+func (f *Flags) Sync() {
+	f.Bool("sync", "Fetch into existing clones instead of re-cloning them", func(cfg *Config, v bool) { cfg.Sync = v })
+}
+
+// Metrics registers the flags of the metrics export
+func (f *Flags) Metrics() {
+	f.String("o", "Write the metrics CSV files to `directory`", func(cfg *Config, v string) { cfg.Metrics.OutputDir = v })
+	f.Bool("scan", "Analyze every repository found under the clone directory instead of the listed ones",
+		func(cfg *Config, v bool) { cfg.Metrics.Scan = v })
+	f.Int("window", "Compute the velocity over the last `days` days", func(cfg *Config, v int) { cfg.Metrics.Window = v })
+	f.Bool("all-branches", "Analyze the commits of every branch and tag instead of HEAD",
+		func(cfg *Config, v bool) { cfg.Metrics.AllBranches = v })
+	f.String("branches", "Analyze the commits of these comma separated `branches` instead of HEAD",
+		func(cfg *Config, v string) { cfg.Metrics.Branches = splitList(v) })
+	f.String("since", "Skip commits authored before `date`, YYYY-MM-DD or RFC 3339",
+		func(cfg *Config, v string) { cfg.Metrics.Since = v })
+	f.String("until", "Skip commits authored after `date`, YYYY-MM-DD or RFC 3339",
+		func(cfg *Config, v string) { cfg.Metrics.Until = v })
+	f.Bool("no-merges", "Skip merge commits", func(cfg *Config, v bool) { cfg.Metrics.Merges = !v })
+	f.String("alias-file", "Map author names and emails with the mailmap `file` in every repository",
+		func(cfg *Config, v string) { cfg.Metrics.AliasFile = v })
+	f.Bool("exclude-bots", "Leave the commits of bots out of the metrics", func(cfg *Config, v bool) { cfg.Metrics.ExcludeBots = v })
+}
+
+// String registers a string flag applied to the configuration when given
+func (f *Flags) String(name, usage string, apply func(*Config, string)) {
+	f.set.Func(name, usage, func(value string) error {
+		f.overrides[name] = func(cfg *Config) { apply(cfg, value) }
+		return nil
+	})
+}
+
+// Int registers an integer flag applied to the configuration when given
+func (f *Flags) Int(name, usage string, apply func(*Config, int)) {
+	f.set.Func(name, usage, func(value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		f.overrides[name] = func(cfg *Config) { apply(cfg, n) }
+		return nil
+	})
+}
+
+// Int64 registers a 64-bit integer flag applied to the configuration when
+// given
+func (f *Flags) Int64(name, usage string, apply func(*Config, int64)) {
+	f.set.Func(name, usage, func(value string) error {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		f.overrides[name] = func(cfg *Config) { apply(cfg, n) }
+		return nil
+	})
+}
+
+// Bool registers a boolean flag applied to the configuration when given
+func (f *Flags) Bool(name, usage string, apply func(*Config, bool)) {
+	f.set.BoolFunc(name, usage, func(value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		f.overrides[name] = func(cfg *Config) { apply(cfg, b) }
+		return nil
+	})
+}
+
+// Load reads the configuration file and applies the flags given on the
+// command line. A missing configuration file is only an error when it was
+// named with -c.
+func (f *Flags) Load() (*Config, error) {
+	explicit := false
+	f.set.Visit(func(fl *flag.Flag) {
+		explicit = explicit || fl.Name == "c"
+	})
+
+	cfg, err := read(f.configFile, explicit)
+	if err != nil {
+		return nil, err
+	}
+
+	// flags are applied in the order they were registered, not the order
+	// they were given in, so repeated runs behave the same
+	f.set.VisitAll(func(fl *flag.Flag) {
+		if apply, ok := f.overrides[fl.Name]; ok {
+			apply(cfg)
+		}
+	})
+
+	if err := cfg.finish(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// splitList splits a comma separated flag value, dropping empty items
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// lower lower-cases and trims a setting
+func lower(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
@@ -0,0 +1,145 @@
+package credentials
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/dmaharana/clone-git-repo/internal/pkg/paths"
+)
+
+// Names of the credential sources, in the order they are tried by default
+const (
+	SourceConfig = "config" // username and token written in the config file
+	SourceFile   = "file"   // token read from the file in token_file
+	SourceEnv    = "env"    // username and token from environment variables
+	SourceNetrc  = "netrc"  // login and password from ~/.netrc
+	SourceGit    = "git"    // git credential helpers, through git credential fill
+)
+
+// DefaultSources is the default lookup order
+var DefaultSources = []string{SourceConfig, SourceFile, SourceEnv, SourceNetrc, SourceGit}
+
+// Credential holds a username and token. A zero Credential means anonymous
+// access.
+type Credential struct {
+	Username string
+	Token    string
+}
+
+// Source looks up the credentials for a repository or API URL
+type Source interface {
+	Lookup(rawURL string) (Credential, bool, error)
+}
+
+// Chain tries each source in turn and returns the first credential found
+type Chain []Source
+
+// Lookup implements Source
+func (c Chain) Lookup(rawURL string) (Credential, bool, error) {
+	for _, source := range c {
+		cred, ok, err := source.Lookup(rawURL)
+		if err != nil {
+			return Credential{}, false, err
+		}
+		if ok {
+			return cred, true, nil
+		}
+	}
+	return Credential{}, false, nil
+}
+
+// Static returns the credential it holds when a token is set
+type Static Credential
+
+// Lookup implements Source
+func (s Static) Lookup(string) (Credential, bool, error) {
+	return Credential(s), s.Token != "", nil
+}
+
+// TokenFile reads the token from a file, so it can be kept out of the config
+// file and managed by a secret store
+type TokenFile struct {
+	Path     string
+	Username string
+}
+
+// Lookup implements Source
+func (f TokenFile) Lookup(string) (Credential, bool, error) {
+	if f.Path == "" {
+		return Credential{}, false, nil
+	}
+
+	data, err := os.ReadFile(paths.ExpandHome(f.Path))
+	if err != nil {
+		return Credential{}, false, fmt.Errorf("failed to read token file: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	return Credential{Username: f.Username, Token: token}, token != "", nil
+}
+
+// Env reads the username and token from environment variables
+type Env struct {
+	UsernameVar string
+	TokenVar    string
+	Username    string // used when UsernameVar is not set
+}
+
+// Lookup implements Source
+func (e Env) Lookup(string) (Credential, bool, error) {
+	token := os.Getenv(e.TokenVar)
+	if token == "" {
+		return Credential{}, false, nil
+	}
+
+	username := e.Username
+	if value := os.Getenv(e.UsernameVar); value != "" {
+		username = value
+	}
+	return Credential{Username: username, Token: token}, true, nil
+}
+
+// EnvVar returns the name of the environment variable holding a setting of
+// a credential profile, e.g. CLONE_GIT_REPO_GITLAB_INTERNAL_TOKEN. The
+// default profile has an empty name.
+func EnvVar(profile, setting string) string {
+	name := "CLONE_GIT_REPO_"
+	if profile != "" {
+		name += strings.Map(func(r rune) rune {
+			if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+				return r
+			}
+			return '_'
+		}, strings.ToUpper(profile)) + "_"
+	}
+	return name + strings.ToUpper(setting)
+}
+
+// target holds the parts of a URL that credentials are looked up by
+type target struct {
+	Protocol string
+	Host     string // host name, with the port if there is one
+}
+
+// parseTarget returns the protocol and host of a URL. SSH URLs are
+// looked up as HTTPS, since that is how they are cloned with a token.
+func parseTarget(rawURL string) (target, bool) {
+	if strings.Contains(rawURL, "://") {
+		u, err := url.Parse(rawURL)
+		if err != nil || u.Host == "" {
+			return target{}, false
+		}
+		protocol := u.Scheme
+		if protocol != "http" {
+			protocol = "https"
+		}
+		return target{Protocol: protocol, Host: u.Host}, true
+	}
+
+	u, err := paths.ParseURL(rawURL)
+	if err != nil || u.Host == "" {
+		return target{}, false
+	}
+	return target{Protocol: "https", Host: u.Host}, true
+}
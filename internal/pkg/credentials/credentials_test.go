@@ -0,0 +1,152 @@
+package credentials
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// fakeSource answers every lookup with its credential and records the call
+type fakeSource struct {
+	name  string
+	cred  Credential
+	found bool
+	err   error
+	calls *[]string
+}
+
+// Lookup implements Source
+func (f fakeSource) Lookup(string) (Credential, bool, error) {
+	*f.calls = append(*f.calls, f.name)
+	return f.cred, f.found, f.err
+}
+
+func TestChain(t *testing.T) {
+	failed := errors.New("lookup failed")
+	tests := []struct {
+		name      string
+		sources   []fakeSource
+		want      Credential
+		wantFound bool
+		wantErr   error
+		wantCalls []string
+	}{
+		{
+			name: "first found wins",
+			sources: []fakeSource{
+				{name: "config"},
+				{name: "env", cred: Credential{Username: "env", Token: "e"}, found: true},
+				{name: "netrc", cred: Credential{Username: "netrc", Token: "n"}, found: true},
+			},
+			want:      Credential{Username: "env", Token: "e"},
+			wantFound: true,
+			wantCalls: []string{"config", "env"},
+		},
+		{
+			name:      "nothing found",
+			sources:   []fakeSource{{name: "config"}, {name: "env"}},
+			wantCalls: []string{"config", "env"},
+		},
+		{
+			name: "error stops the chain",
+			sources: []fakeSource{
+				{name: "file", err: failed},
+				{name: "env", cred: Credential{Token: "e"}, found: true},
+			},
+			wantErr:   failed,
+			wantCalls: []string{"file"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			var chain Chain
+			for _, source := range tt.sources {
+				source.calls = &calls
+				chain = append(chain, source)
+			}
+
+			got, found, err := chain.Lookup("https://example.com/acme/tool.git")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Lookup() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want || found != tt.wantFound {
+				t.Errorf("Lookup() = %+v, %t, want %+v, %t", got, found, tt.want, tt.wantFound)
+			}
+			if !reflect.DeepEqual(calls, tt.wantCalls) {
+				t.Errorf("sources asked %v, want %v", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestEnv(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       map[string]string
+		want      Credential
+		wantFound bool
+	}{
+		{"no token", map[string]string{"TEST_USER": "alice"}, Credential{}, false},
+		{"token with default user", map[string]string{"TEST_TOKEN": "t"}, Credential{Username: "oauth2", Token: "t"}, true},
+		{"token and user", map[string]string{"TEST_TOKEN": "t", "TEST_USER": "alice"}, Credential{Username: "alice", Token: "t"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TEST_TOKEN", "")
+			t.Setenv("TEST_USER", "")
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			env := Env{UsernameVar: "TEST_USER", TokenVar: "TEST_TOKEN", Username: "oauth2"}
+			got, found, err := env.Lookup("https://example.com/acme/tool.git")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want || found != tt.wantFound {
+				t.Errorf("Lookup() = %+v, %t, want %+v, %t", got, found, tt.want, tt.wantFound)
+			}
+		})
+	}
+}
+
+func TestTokenFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name      string
+		file      TokenFile
+		want      Credential
+		wantFound bool
+		wantErr   bool
+	}{
+		{"no file", TokenFile{}, Credential{}, false, false},
+		{"token is trimmed", TokenFile{Path: write("token", "secret\n"), Username: "alice"}, Credential{Username: "alice", Token: "secret"}, true, false},
+		{"empty file", TokenFile{Path: write("empty", " \n")}, Credential{}, false, false},
+		{"missing file", TokenFile{Path: filepath.Join(dir, "missing")}, Credential{}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found, err := tt.file.Lookup("https://example.com/acme/tool.git")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Lookup() error = %v, want error %t", err, tt.wantErr)
+			}
+			if got != tt.want || found != tt.wantFound {
+				t.Errorf("Lookup() = %+v, %t, want %+v, %t", got, found, tt.want, tt.wantFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package credentials
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+var (
+	// answers of git credential fill by request, so parallel clones from
+	// the same host run the helpers once
+	helperCache   = make(map[string]*helperAnswer)
+	helperCacheMu sync.Mutex
+)
+
+// helperAnswer is the answer of the helpers to one request, filled once
+// while lookups of other hosts go on
+type helperAnswer struct {
+	once sync.Once
+	cred Credential
+	err  error
+}
+
+// GitHelper asks the git credential helpers configured for the user, such
+// as a keychain or Git Credential Manager, through git credential fill.
+// Git is never allowed to prompt on the terminal.
+type GitHelper struct {
+	Username string // passed to the helpers when known
+}
+
+// Lookup implements Source
+func (g GitHelper) Lookup(rawURL string) (Credential, bool, error) {
+	t, ok := parseTarget(rawURL)
+	if !ok {
+		return Credential{}, false, nil
+	}
+
+	// the path is left out, as git does unless credential.useHttpPath is
+	// set, so every repository on a host shares one answer
+	var request strings.Builder
+	fmt.Fprintf(&request, "protocol=%s\nhost=%s\n", t.Protocol, t.Host)
+	if g.Username != "" {
+		fmt.Fprintf(&request, "username=%s\n", g.Username)
+	}
+	request.WriteString("\n")
+
+	key := request.String()
+	helperCacheMu.Lock()
+	answer, ok := helperCache[key]
+	if !ok {
+		answer = &helperAnswer{}
+		helperCache[key] = answer
+	}
+	helperCacheMu.Unlock()
+
+	answer.once.Do(func() {
+		answer.cred, answer.err = fillCredential(key)
+	})
+	if answer.err != nil {
+		return Credential{}, false, answer.err
+	}
+	return answer.cred, answer.cred.Token != "", nil
+}
+
+// fillCredential runs git credential fill. A missing git binary or a helper
+// that has nothing to offer yields no credential rather than an error.
+func fillCredential(request string) (Credential, error) {
+	cmd := exec.Command("git", "credential", "fill")
+	cmd.Stdin = strings.NewReader(request)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=", "SSH_ASKPASS=")
+
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.Is(err, exec.ErrNotFound) || errors.As(err, &exitErr) {
+		return Credential{}, nil
+	}
+	if err != nil {
+		return Credential{}, fmt.Errorf("failed to run git credential fill: %w", err)
+	}
+
+	var cred Credential
+	scanner := bufio.NewScanner(&stdout)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "username":
+			cred.Username = value
+		case "password":
+			cred.Token = value
+		}
+	}
+
+	return cred, scanner.Err()
+}
@@ -0,0 +1,88 @@
+package credentials
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeGit puts a git on PATH that logs every credential request to a file
+// and answers with a password naming the host
+func fakeGit(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake git is a shell script")
+	}
+
+	dir := t.TempDir()
+	requests := filepath.Join(dir, "requests")
+	script := "#!/bin/sh\n" +
+		"request=$(cat)\n" +
+		"printf '%s\\n' \"$(echo \"$request\" | tr '\\n' ' ')\" >> " + requests + "\n" +
+		"host=$(echo \"$request\" | sed -n 's/^host=//p')\n" +
+		"echo username=user\n" +
+		"echo password=token-$host\n"
+	if err := os.WriteFile(filepath.Join(dir, "git"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	helperCacheMu.Lock()
+	helperCache = make(map[string]*helperAnswer)
+	helperCacheMu.Unlock()
+	return requests
+}
+
+func TestGitHelperAsksOncePerHost(t *testing.T) {
+	requests := fakeGit(t)
+
+	urls := []string{
+		"https://github.com/acme/one.git",
+		"https://github.com/acme/two.git",
+		"git@github.com:other/three.git",
+		"https://gitlab.com/acme/one.git",
+	}
+
+	var wg sync.WaitGroup
+	creds := make([]Credential, len(urls))
+	for i, rawURL := range urls {
+		wg.Add(1)
+		go func(i int, rawURL string) {
+			defer wg.Done()
+			cred, found, err := GitHelper{}.Lookup(rawURL)
+			if err != nil || !found {
+				t.Errorf("Lookup(%q) = %t, %v, want a credential", rawURL, found, err)
+			}
+			creds[i] = cred
+		}(i, rawURL)
+	}
+	wg.Wait()
+
+	for i, rawURL := range urls {
+		host := "github.com"
+		if strings.Contains(rawURL, "gitlab") {
+			host = "gitlab.com"
+		}
+		if want := (Credential{Username: "user", Token: "token-" + host}); creds[i] != want {
+			t.Errorf("Lookup(%q) = %+v, want %+v", rawURL, creds[i], want)
+		}
+	}
+
+	data, err := os.ReadFile(requests)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		got = append(got, strings.Join(strings.Fields(line), " "))
+	}
+	sort.Strings(got)
+	want := []string{"protocol=https host=github.com", "protocol=https host=gitlab.com"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("git credential fill asked %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,175 @@
+package credentials
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/dmaharana/clone-git-repo/internal/pkg/paths"
+)
+
+// netrcEntry is a machine or default entry of a netrc file
+type netrcEntry struct {
+	Machine  string // empty for the default entry
+	Login    string
+	Password string
+}
+
+var (
+	// parsed netrc files by path, shared by parallel lookups
+	netrcCache   = make(map[string][]netrcEntry)
+	netrcCacheMu sync.Mutex
+)
+
+// Netrc looks up the login and password of the URL's host in a netrc file
+type Netrc struct {
+	Path string // netrc file, empty for $NETRC or ~/.netrc
+}
+
+// Lookup implements Source
+func (n Netrc) Lookup(rawURL string) (Credential, bool, error) {
+	t, ok := parseTarget(rawURL)
+	if !ok {
+		return Credential{}, false, nil
+	}
+
+	entries, err := loadNetrc(n.path())
+	if err != nil {
+		return Credential{}, false, err
+	}
+
+	host := t.Host
+	if i := strings.LastIndex(host, ":"); i >= 0 && !strings.HasSuffix(host, "]") {
+		host = host[:i]
+	}
+
+	var fallback *netrcEntry
+	for i, entry := range entries {
+		switch {
+		case entry.Machine == "":
+			if fallback == nil {
+				fallback = &entries[i]
+			}
+		case strings.EqualFold(entry.Machine, t.Host), strings.EqualFold(entry.Machine, host):
+			return Credential{Username: entry.Login, Token: entry.Password}, entry.Password != "", nil
+		}
+	}
+	if fallback != nil {
+		return Credential{Username: fallback.Login, Token: fallback.Password}, fallback.Password != "", nil
+	}
+
+	return Credential{}, false, nil
+}
+
+// path returns the netrc file to read
+func (n Netrc) path() string {
+	if n.Path != "" {
+		return paths.ExpandHome(n.Path)
+	}
+	if path := os.Getenv("NETRC"); path != "" {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	name := ".netrc"
+	if runtime.GOOS == "windows" {
+		name = "_netrc"
+	}
+	return filepath.Join(home, name)
+}
+
+// loadNetrc parses a netrc file once. A missing file has no entries.
+func loadNetrc(path string) ([]netrcEntry, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	netrcCacheMu.Lock()
+	defer netrcCacheMu.Unlock()
+
+	if entries, ok := netrcCache[path]; ok {
+		return entries, nil
+	}
+
+	entries, err := parseNetrc(path)
+	if errors.Is(err, os.ErrNotExist) {
+		err = nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read netrc file: %w", err)
+	}
+
+	netrcCache[path] = entries
+	return entries, nil
+}
+
+// parseNetrc reads the machine and default entries of a netrc file,
+// skipping macro definitions
+func parseNetrc(path string) ([]netrcEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []netrcEntry
+	var current *netrcEntry
+	inMacro := false
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if inMacro {
+			// a macro definition ends at the first blank line
+			inMacro = strings.TrimSpace(line) != ""
+			continue
+		}
+
+		fields := strings.Fields(line)
+		for i := 0; i < len(fields); i++ {
+			if strings.HasPrefix(fields[i], "#") {
+				break
+			}
+
+			value := ""
+			if i+1 < len(fields) {
+				value = fields[i+1]
+			}
+
+			switch fields[i] {
+			case "machine":
+				entries = append(entries, netrcEntry{Machine: value})
+				current = &entries[len(entries)-1]
+				i++
+			case "default":
+				entries = append(entries, netrcEntry{})
+				current = &entries[len(entries)-1]
+			case "login":
+				if current != nil {
+					current.Login = value
+				}
+				i++
+			case "password":
+				if current != nil {
+					current.Password = value
+				}
+				i++
+			case "account":
+				i++
+			case "macdef":
+				inMacro = true
+				i = len(fields)
+			}
+		}
+	}
+
+	return entries, scanner.Err()
+}
@@ -0,0 +1,105 @@
+package credentials
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeNetrc writes a netrc file into a temporary directory
+func writeNetrc(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "netrc")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseNetrc(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []netrcEntry
+	}{
+		{
+			name:    "one line",
+			content: "machine github.com login alice password secret\n",
+			want:    []netrcEntry{{Machine: "github.com", Login: "alice", Password: "secret"}},
+		},
+		{
+			name: "one token per line with account",
+			content: "machine gitlab.com\n" +
+				"  login bob\n" +
+				"  account ops\n" +
+				"  password glpat\n",
+			want: []netrcEntry{{Machine: "gitlab.com", Login: "bob", Password: "glpat"}},
+		},
+		{
+			name: "comments and default",
+			content: "# personal\n" +
+				"machine a.example.com login a password pa # trailing\n" +
+				"default login anonymous password pd\n",
+			want: []netrcEntry{
+				{Machine: "a.example.com", Login: "a", Password: "pa"},
+				{Login: "anonymous", Password: "pd"},
+			},
+		},
+		{
+			name: "macro skipped until blank line",
+			content: "macdef init\n" +
+				"machine fake login x password y\n" +
+				"\n" +
+				"machine b.example.com login b password pb\n",
+			want: []netrcEntry{{Machine: "b.example.com", Login: "b", Password: "pb"}},
+		},
+		{
+			name:    "login before any machine",
+			content: "login stray password stray\n",
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseNetrc(writeNetrc(t, tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseNetrc() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNetrcLookup(t *testing.T) {
+	path := writeNetrc(t, "machine git.example.com:8443 login port password pp\n"+
+		"machine GitHub.com login alice password pa\n"+
+		"default login anonymous password pd\n")
+
+	tests := []struct {
+		url  string
+		want Credential
+	}{
+		{"https://github.com/acme/tool.git", Credential{Username: "alice", Token: "pa"}},
+		{"git@github.com:acme/tool.git", Credential{Username: "alice", Token: "pa"}},
+		{"https://git.example.com:8443/acme/tool.git", Credential{Username: "port", Token: "pp"}},
+		{"https://gitlab.com/acme/tool.git", Credential{Username: "anonymous", Token: "pd"}},
+	}
+
+	for _, tt := range tests {
+		got, found, err := Netrc{Path: path}.Lookup(tt.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !found || got != tt.want {
+			t.Errorf("Lookup(%q) = %+v, %t, want %+v", tt.url, got, found, tt.want)
+		}
+	}
+
+	if _, found, err := (Netrc{Path: filepath.Join(t.TempDir(), "missing")}).Lookup("https://github.com/a/b"); err != nil || found {
+		t.Errorf("Lookup() with a missing file = %t, %v, want nothing found", found, err)
+	}
+}
@@ -2,11 +2,79 @@ package csv
 
 import (
 	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dmaharana/clone-git-repo/internal/pkg/config"
 )
 
-// ReadRepositoryURLs reads repository URLs from a CSV file
-func ReadRepositoryURLs(filename string) ([]string, error) {
+// driveRegex matches a Windows drive letter, which also makes a path
+// relative to another drive's working directory
+var driveRegex = regexp.MustCompile(`^[A-Za-z]:`)
+
+// Column names understood in the CSV header
+const (
+	ColumnURL               = "url"
+	ColumnDest              = "dest"
+	ColumnBranch            = "branch"
+	ColumnDepth             = "depth"
+	ColumnMode              = "mode"
+	ColumnCredentialProfile = "credential_profile"
+	ColumnTags              = "tags"
+	ColumnAuth              = "auth"
+)
+
+// columnAliases maps alternative header names to their column
+var columnAliases = map[string]string{
+	"repo_url":   ColumnURL,
+	"repository": ColumnURL,
+	"profile":    ColumnCredentialProfile,
+	"labels":     ColumnTags,
+}
+
+// positionalColumns is the column order used when the file has no header
+var positionalColumns = []string{ColumnURL, ColumnMode}
+
+// RepoSpec describes a single repository to clone
+type RepoSpec struct {
+	URL               string
+	Dest              string   // optional directory relative to the clone directory
+	Branch            string   // optional, clone only this branch
+	Depth             int      // optional shallow clone depth, 0 for full history
+	Mode              string   // optional, overrides the run-level clone mode
+	CredentialProfile string   // optional credential profile name
+	Tags              []string // optional labels, separated by ';' in the file
+	Auth              string   // optional, https or ssh, overrides the run-level auth method
+	Line              int      // line in the source file, for error reporting
+}
+
+// RowError reports an invalid row in the CSV file
+type RowError struct {
+	File string
+	Line int
+	Err  error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// ReadRepositories reads repositories from a CSV file. The first row is a
+// header naming the columns (url, dest, branch, depth, mode,
+// credential_profile, tags, auth); only url is required. Files without a known
+// header are read as url and an optional mode. Blank lines and lines
+// starting with '#' are ignored. All invalid rows are reported together.
+func ReadRepositories(filename string) ([]RepoSpec, error) {
 	file, err := os.Open(filename)
 	if err != nil {
 		return nil, err
@@ -14,14 +82,180 @@ func ReadRepositoryURLs(filename string) ([]string, error) {
 	defer file.Close()
 
 	csvReader := csv.NewReader(file)
-	records, err := csvReader.ReadAll()
+	csvReader.FieldsPerRecord = -1
+	csvReader.Comment = '#'
+	csvReader.TrimLeadingSpace = true
+
+	var columns map[string]int
+	var repositories []RepoSpec
+	var rowErrors []error
+
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := csvReader.FieldPos(0)
+
+		if columns == nil {
+			var isHeader bool
+			columns, isHeader, err = parseHeader(record)
+			if err != nil {
+				return nil, &RowError{File: filename, Line: line, Err: err}
+			}
+			if isHeader {
+				continue
+			}
+		}
+
+		spec, err := parseRecord(record, columns)
+		if err != nil {
+			rowErrors = append(rowErrors, &RowError{File: filename, Line: line, Err: err})
+			continue
+		}
+		if spec.URL == "" {
+			// a row of empty cells
+			continue
+		}
+		spec.Line = line
+		repositories = append(repositories, spec)
+	}
+
+	if len(rowErrors) > 0 {
+		return nil, errors.Join(rowErrors...)
+	}
+
+	return repositories, nil
+}
+
+// parseHeader maps column names to their index. If the record is not a
+// header, the positional columns are returned instead.
+func parseHeader(record []string) (map[string]int, bool, error) {
+	columns := make(map[string]int)
+	for i, name := range record {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if alias, ok := columnAliases[name]; ok {
+			name = alias
+		}
+		if _, ok := columns[name]; ok && name != "" {
+			return nil, false, fmt.Errorf("duplicate column %q", name)
+		}
+		columns[name] = i
+	}
+
+	if _, ok := columns[ColumnURL]; !ok {
+		// no known header, fall back to positional columns. A first cell
+		// that cannot be a repository location is an unknown header.
+		first := ""
+		if len(record) > 0 {
+			first = record[0]
+		}
+		isHeader := !strings.ContainsAny(first, "/:\\")
+
+		columns = make(map[string]int)
+		for i, name := range positionalColumns {
+			columns[name] = i
+		}
+		return columns, isHeader, nil
+	}
+
+	return columns, true, nil
+}
+
+// parseRecord builds and validates a RepoSpec from a CSV record
+func parseRecord(record []string, columns map[string]int) (RepoSpec, error) {
+	field := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	spec := RepoSpec{
+		URL:               field(ColumnURL),
+		Dest:              field(ColumnDest),
+		Branch:            field(ColumnBranch),
+		Mode:              strings.ToLower(field(ColumnMode)),
+		CredentialProfile: field(ColumnCredentialProfile),
+		Auth:              strings.ToLower(field(ColumnAuth)),
+	}
+
+	if spec.URL == "" {
+		for _, value := range record {
+			if strings.TrimSpace(value) != "" {
+				return spec, fmt.Errorf("missing %s", ColumnURL)
+			}
+		}
+		return spec, nil
+	}
+
+	if spec.Mode != "" && spec.Mode != config.ModeSkip && !config.ValidMode(spec.Mode) {
+		return spec, fmt.Errorf("unknown mode %q, expected %s, %s or %s", spec.Mode, config.ModeClone, config.ModeMirror, config.ModeSkip)
+	}
+
+	if spec.Auth != "" && !config.ValidAuth(spec.Auth) {
+		return spec, fmt.Errorf("unknown auth %q, expected %s or %s", spec.Auth, config.AuthHTTPS, config.AuthSSH)
+	}
+
+	if depth := field(ColumnDepth); depth != "" {
+		n, err := strconv.Atoi(depth)
+		if err != nil || n < 0 {
+			return spec, fmt.Errorf("invalid depth %q, expected a positive number", depth)
+		}
+		spec.Depth = n
+	}
+
+	if spec.Dest != "" {
+		if err := checkDest(spec.Dest); err != nil {
+			return spec, err
+		}
+	}
+
+	for _, tag := range strings.Split(field(ColumnTags), ";") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			spec.Tags = append(spec.Tags, tag)
+		}
+	}
+
+	return spec, nil
+}
+
+// checkDest fails unless dest names a directory strictly inside the clone
+// directory. Windows forms such as C:\x and \\host\share are rejected on
+// every platform, so a CSV file behaves the same wherever it is used.
+func checkDest(dest string) error {
+	slashed := strings.ReplaceAll(dest, "\\", "/")
+	if filepath.IsAbs(dest) || filepath.VolumeName(dest) != "" ||
+		strings.HasPrefix(slashed, "/") || driveRegex.MatchString(slashed) {
+		return fmt.Errorf("dest %q must be relative to the clone directory", dest)
+	}
+	for _, part := range strings.Split(slashed, "/") {
+		if part == ".." {
+			return fmt.Errorf("dest %q leaves the clone directory", dest)
+		}
+	}
+	// the clone directory itself would be removed when the repository is
+	// re-cloned
+	if filepath.Clean(filepath.FromSlash(slashed)) == "." {
+		return fmt.Errorf("dest %q must name a directory inside the clone directory", dest)
+	}
+	return nil
+}
+
+// ReadRepositoryURLs reads repository URLs from a CSV file
+func ReadRepositoryURLs(filename string) ([]string, error) {
+	repositories, err := ReadRepositories(filename)
 	if err != nil {
 		return nil, err
 	}
 
 	var repositoryURLs []string
-	for _, record := range records[1:] { // Skip the header
-		repositoryURLs = append(repositoryURLs, record[0])
+	for _, repository := range repositories {
+		repositoryURLs = append(repositoryURLs, repository.URL)
 	}
 
 	return repositoryURLs, nil
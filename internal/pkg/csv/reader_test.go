@@ -0,0 +1,30 @@
+package csv
+
+import "testing"
+
+func TestCheckDest(t *testing.T) {
+	tests := []struct {
+		dest string
+		ok   bool
+	}{
+		{"team/utils", true},
+		{"./utils", true},
+		{"a/./b", true},
+		{".", false},
+		{"./", false},
+		{"a/..", false},
+		{"../x", false},
+		{"/srv/x", false},
+		{`C:\x`, false},
+		{"c:x", false},
+		{`\\host\share`, false},
+		{`\x`, false},
+	}
+
+	for _, tt := range tests {
+		err := checkDest(tt.dest)
+		if (err == nil) != tt.ok {
+			t.Errorf("checkDest(%q) = %v, want ok %t", tt.dest, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package csv
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// columnOrder is the order in which WriteRepositories writes the columns
+var columnOrder = []string{
+	ColumnURL, ColumnDest, ColumnBranch, ColumnDepth, ColumnMode,
+	ColumnCredentialProfile, ColumnTags, ColumnAuth,
+}
+
+// WriteRepositories writes repositories in the format read by
+// ReadRepositories, with a header and only the columns that are used
+func WriteRepositories(w io.Writer, repositories []RepoSpec) error {
+	used := map[string]bool{ColumnURL: true}
+	for _, spec := range repositories {
+		for column, value := range specFields(spec) {
+			if value != "" {
+				used[column] = true
+			}
+		}
+	}
+
+	var header []string
+	for _, column := range columnOrder {
+		if used[column] {
+			header = append(header, column)
+		}
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, spec := range repositories {
+		fields := specFields(spec)
+		record := make([]string, len(header))
+		for i, column := range header {
+			record[i] = fields[column]
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// specFields returns the column values of a RepoSpec
+func specFields(spec RepoSpec) map[string]string {
+	depth := ""
+	if spec.Depth > 0 {
+		depth = strconv.Itoa(spec.Depth)
+	}
+	return map[string]string{
+		ColumnURL:               spec.URL,
+		ColumnDest:              spec.Dest,
+		ColumnBranch:            spec.Branch,
+		ColumnDepth:             depth,
+		ColumnMode:              spec.Mode,
+		ColumnCredentialProfile: spec.CredentialProfile,
+		ColumnTags:              strings.Join(spec.Tags, ";"),
+		ColumnAuth:              spec.Auth,
+	}
+}
@@ -1,10 +1,11 @@
 package git
 
 import (
-	"log"
+	"io"
 	"os"
 	"strings"
 
+	"github.com/dmaharana/clone-git-repo/internal/pkg/logger"
 	"github.com/dmaharana/clone-git-repo/internal/repostatus"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
@@ -16,97 +17,101 @@ const (
 	gitOrigin = "origin"
 )
 
-// CloneRepo clones a Git repository and checks out all its branches
-func CloneRepo(url string, dir string, rs *repostatus.RepoStatus, username, token string) error {
-	var auth transport.AuthMethod
-	cloneURL := url
-
-	// If token is provided, default to HTTPS and use authentication
-	if token != "" {
-		if strings.HasPrefix(url, "git@") {
-			// Convert SSH to HTTPS
-			// git@github.com:user/repo.git -> https://github.com/user/repo.git
-			cloneURL = strings.Replace(url, ":", "/", 1)
-			cloneURL = strings.Replace(cloneURL, "git@", "https://", 1)
-		} else if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
-			// If it doesn't have a protocol, assume it's a path and try to make it HTTPS if it looks like one
-			// This is a bit speculative, but common for some inputs
-			if strings.Contains(url, "github.com") || strings.Contains(url, "gitlab.com") || strings.Contains(url, "bitbucket.org") {
-				cloneURL = "https://" + url
-			}
-		}
+// Options controls how a repository is cloned or updated
+type Options struct {
+	Username string
+	Token    string
+	Branch   string      // clone only this branch when set
+	Depth    int         // shallow clone depth, 0 for full history
+	UseSSH   bool        // clone SSH URLs over SSH instead of converting them to HTTPS
+	SSH      *SSHOptions // SSH authentication, used when UseSSH is set
+	// Progress receives the progress output of the remote, nil to discard
+	// it, as parallel clones would interleave their lines
+	Progress io.Writer
+}
 
-		if strings.HasPrefix(cloneURL, "https://") || strings.HasPrefix(cloneURL, "http://") {
-			auth = &http.BasicAuth{
-				Username: username,
-				Password: token,
-			}
-		}
+// CloneRepo clones a Git repository and checks out all its branches, or only
+// the branch selected in opts
+func CloneRepo(url string, dir string, rs *repostatus.RepoStatus, opts *Options) error {
+	cloneURL, auth, err := resolveAuth(url, opts)
+	if err != nil {
+		return err
 	}
 
-	// clone repo
-	r, err := git.PlainClone(dir, false, &git.CloneOptions{
+	cloneOpts := &git.CloneOptions{
 		URL:      cloneURL,
 		Auth:     auth,
-		Progress: os.Stdout,
-	})
+		Depth:    opts.Depth,
+		Progress: opts.Progress,
+	}
+	if opts.Branch != "" {
+		cloneOpts.ReferenceName = plumbing.NewBranchReferenceName(opts.Branch)
+		cloneOpts.SingleBranch = true
+	}
+
+	// clone repo
+	r, err := git.PlainClone(dir, false, cloneOpts)
 
 	if err != nil {
 		// Do not log the error here if it's authentication related,
 		// let the caller handle it to avoid logging sensitive URLs if any
-		return err
+		return wrapAuthError(err, auth)
 	}
 
 	// checkout one branch at a time and search for terms
 	bList, err := findAllBranches(r)
 	if err != nil {
-		log.Println("Error getting branches:", err)
+		log.Warn("Failed to list branches", logger.KeyDir, dir, logger.KeyError, err)
 		return err
 	}
 
-	log.Println("Branches: ", bList)
+	log.Debug("Branches", logger.KeyDir, dir, "branches", bList)
 
 	// update repo status
 	rs.IsCloned = true
 	rs.BranchCount = len(bList)
 
-	log.Printf("Repository cloned to %s\n", dir)
+	log.Info("Repository cloned", logger.KeyDir, dir, "branches", len(bList))
 
 	// list all tags
 	tList, err := findAllTags(r)
 	if err != nil {
-		log.Println("Error getting tags:", err)
+		log.Warn("Failed to list tags", logger.KeyDir, dir, logger.KeyError, err)
 	}
 
-	log.Println("Tags: ", tList)
+	log.Debug("Tags", logger.KeyDir, dir, "tags", tList)
 
 	// update repo status
 	rs.TagCount = len(tList)
 
+	if opts.Branch != "" {
+		// the requested branch is already checked out
+		return nil
+	}
+
 	// set up worktree
 	w, err := r.Worktree()
 	if err != nil {
-		log.Println("Error getting worktree:", err)
+		log.Warn("Failed to open worktree", logger.KeyDir, dir, logger.KeyError, err)
 		return err
 	}
 
 	// checkout all branches
 	for _, branch := range bList {
-		log.Println("Checking out branch: ", branch)
 		// replace "refs/remotes/origin/" at the beginning of the remote branch name with blank
 		localBranch := strings.Replace(branch, "refs/remotes/origin/", "", 1)
 
 		w.Pull(&git.PullOptions{RemoteName: gitOrigin})
 
 		// checkout the branch
-		log.Println("Checking out branch: ", localBranch)
+		log.Debug("Checking out branch", logger.KeyDir, dir, "branch", localBranch)
 		err = w.Checkout(&git.CheckoutOptions{
 			Branch: plumbing.NewBranchReferenceName(localBranch),
 			Create: true, // Create the branch if it doesn't exist locally
 			Force:  true, // Force checkout
 		})
 		if err != nil {
-			log.Println("Error checking out branch: ", err)
+			log.Warn("Failed to check out branch", logger.KeyDir, dir, "branch", localBranch, logger.KeyError, err)
 			continue
 		}
 	}
@@ -114,8 +119,45 @@ func CloneRepo(url string, dir string, rs *repostatus.RepoStatus, username, toke
 	return nil
 }
 
+// resolveAuth returns the URL to use for the remote together with the
+// authentication method for it
+func resolveAuth(url string, opts *Options) (string, transport.AuthMethod, error) {
+	if opts.UseSSH && IsSSHURL(url) {
+		auth, err := sshAuth(url, opts.SSH)
+		return url, auth, err
+	}
+
+	var auth transport.AuthMethod
+	cloneURL := url
+	username, token := opts.Username, opts.Token
+
+	// If token is provided, default to HTTPS and use authentication
+	if token != "" {
+		if strings.HasPrefix(url, "git@") {
+			// Convert SSH to HTTPS
+			// git@github.com:user/repo.git -> https://github.com/user/repo.git
+			cloneURL = strings.Replace(url, ":", "/", 1)
+			cloneURL = strings.Replace(cloneURL, "git@", "https://", 1)
+		} else if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+			// If it doesn't have a protocol, assume it's a path and try to make it HTTPS if it looks like one
+			// This is a bit speculative, but common for some inputs
+			if strings.Contains(url, "github.com") || strings.Contains(url, "gitlab.com") || strings.Contains(url, "bitbucket.org") {
+				cloneURL = "https://" + url
+			}
+		}
+
+		if strings.HasPrefix(cloneURL, "https://") || strings.HasPrefix(cloneURL, "http://") {
+			auth = &http.BasicAuth{
+				Username: username,
+				Password: token,
+			}
+		}
+	}
+
+	return cloneURL, auth, nil
+}
+
 func findAllBranches(r *git.Repository) ([]string, error) {
-	log.Println("Branches: ")
 	branches, err := r.References()
 	if err != nil {
 		return nil, nil
@@ -137,14 +179,13 @@ func findAllBranches(r *git.Repository) ([]string, error) {
 		return nil
 	})
 
-	log.Printf("Total branch(es): %d\n", count)
+	log.Debug("Found branches", "count", count)
 
 	return branchList, nil
 }
 
 // find all tags
 func findAllTags(r *git.Repository) ([]string, error) {
-	log.Println("Tags: ")
 	tags, err := r.Tags()
 	if err != nil {
 		return nil, nil
@@ -165,7 +206,7 @@ func findAllTags(r *git.Repository) ([]string, error) {
 		return nil
 	})
 
-	log.Printf("Total tag(s): %d\n", count)
+	log.Debug("Found tags", "count", count)
 
 	return tagList, nil
 }
@@ -0,0 +1,141 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// ErrorClass identifies the kind of failure of a git operation
+type ErrorClass string
+
+// Known error classes
+const (
+	ErrorNone          ErrorClass = ""
+	ErrorAuthRequired  ErrorClass = "auth_required"
+	ErrorAuthFailed    ErrorClass = "auth_failed"
+	ErrorRepoNotFound  ErrorClass = "repo_not_found"
+	ErrorNetwork       ErrorClass = "network"
+	ErrorServer        ErrorClass = "server_error"
+	ErrorAlreadyExists ErrorClass = "already_exists"
+	ErrorEmptyRepo     ErrorClass = "empty_repo"
+	ErrorDiskFull      ErrorClass = "disk_full"
+	ErrorCancelled     ErrorClass = "cancelled"
+	ErrorUnknown       ErrorClass = "unknown"
+)
+
+// Error attaches an error class to an error that cannot be classified from
+// its chain alone
+type Error struct {
+	Class ErrorClass
+	Err   error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Classify returns the class of err
+func Classify(err error) ErrorClass {
+	if err == nil {
+		return ErrorNone
+	}
+
+	var classified *Error
+	if errors.As(err, &classified) {
+		return classified.Class
+	}
+
+	// unknown or changed SSH host keys
+	var keyErr *knownhosts.KeyError
+	if errors.As(err, &keyErr) {
+		return ErrorAuthFailed
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return ErrorCancelled
+	case errors.Is(err, transport.ErrAuthenticationRequired):
+		return ErrorAuthRequired
+	case errors.Is(err, transport.ErrAuthorizationFailed),
+		errors.Is(err, transport.ErrInvalidAuthMethod):
+		return ErrorAuthFailed
+	case errors.Is(err, transport.ErrRepositoryNotFound):
+		return ErrorRepoNotFound
+	case errors.Is(err, git.ErrRepositoryAlreadyExists),
+		errors.Is(err, os.ErrExist):
+		return ErrorAlreadyExists
+	case errors.Is(err, transport.ErrEmptyRemoteRepository):
+		return ErrorEmptyRepo
+	case errors.Is(err, syscall.ENOSPC):
+		return ErrorDiskFull
+	case isNetworkError(err):
+		return ErrorNetwork
+	case isServerError(err):
+		return ErrorServer
+	}
+
+	return ErrorUnknown
+}
+
+// isNetworkError reports whether err was caused by the connection to the remote
+func isNetworkError(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	return errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, os.ErrDeadlineExceeded) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ETIMEDOUT)
+}
+
+// isServerError reports whether the remote answered with a 5xx status, or
+// asked to slow down with 429, both of which usually pass
+func isServerError(err error) bool {
+	// go-git's UnexpectedError does not unwrap, so it is opened by hand
+	var unexpected *plumbing.UnexpectedError
+	if errors.As(err, &unexpected) {
+		err = unexpected.Err
+	}
+
+	var httpErr *http.Err
+	if !errors.As(err, &httpErr) || httpErr.Response == nil {
+		return false
+	}
+	status := httpErr.StatusCode()
+	return status >= 500 || status == 429
+}
+
+// wrapAuthError marks an authentication request as a failure when
+// credentials were supplied, since the remote has rejected them
+func wrapAuthError(err error, auth transport.AuthMethod) error {
+	if err == nil || auth == nil {
+		return err
+	}
+	if errors.Is(err, transport.ErrAuthenticationRequired) {
+		return &Error{Class: ErrorAuthFailed, Err: err}
+	}
+	// the ssh package has no sentinel for rejected keys, only this message
+	if strings.Contains(err.Error(), "ssh: unable to authenticate") {
+		return &Error{Class: ErrorAuthFailed, Err: err}
+	}
+	return err
+}
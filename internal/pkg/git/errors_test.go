@@ -0,0 +1,83 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	nethttp "net/http"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorClass
+	}{
+		{"nil", nil, ErrorNone},
+		{"explicit class", fmt.Errorf("clone: %w", &Error{Class: ErrorAuthFailed, Err: errors.New("denied")}), ErrorAuthFailed},
+		{"auth required", transport.ErrAuthenticationRequired, ErrorAuthRequired},
+		{"auth failed", fmt.Errorf("fetch: %w", transport.ErrAuthorizationFailed), ErrorAuthFailed},
+		{"invalid auth method", transport.ErrInvalidAuthMethod, ErrorAuthFailed},
+		{"changed host key", &knownhosts.KeyError{}, ErrorAuthFailed},
+		{"not found", fmt.Errorf("clone: %w", transport.ErrRepositoryNotFound), ErrorRepoNotFound},
+		{"already exists", git.ErrRepositoryAlreadyExists, ErrorAlreadyExists},
+		{"empty", transport.ErrEmptyRemoteRepository, ErrorEmptyRepo},
+		{"disk full", &os.PathError{Op: "write", Path: "x", Err: syscall.ENOSPC}, ErrorDiskFull},
+		{"cancelled", context.Canceled, ErrorCancelled},
+		{"connection refused", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, ErrorNetwork},
+		{"dns timeout", &net.DNSError{Err: "timeout", Name: "example.com", IsTimeout: true}, ErrorNetwork},
+		{"connection reset", fmt.Errorf("read: %w", syscall.ECONNRESET), ErrorNetwork},
+		{"context timeout", context.DeadlineExceeded, ErrorNetwork},
+		{"deadline exceeded", os.ErrDeadlineExceeded, ErrorNetwork},
+		{"unexpected eof", io.ErrUnexpectedEOF, ErrorNetwork},
+		{"server error", plumbing.NewUnexpectedError(&http.Err{Response: response(502)}), ErrorServer},
+		{"rate limited", plumbing.NewUnexpectedError(&http.Err{Response: response(429)}), ErrorServer},
+		{"other status", plumbing.NewUnexpectedError(&http.Err{Response: response(418)}), ErrorUnknown},
+		{"unknown", errors.New("something else"), ErrorUnknown},
+	}
+
+	for _, tt := range tests {
+		if got := Classify(tt.err); got != tt.want {
+			t.Errorf("%s: Classify(%v) = %q, want %q", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+// response returns an HTTP response with the given status
+func response(status int) *nethttp.Response {
+	req, _ := nethttp.NewRequest(nethttp.MethodGet, "https://example.com/repo.git/info/refs", nil)
+	return &nethttp.Response{StatusCode: status, Request: req}
+}
+
+func TestWrapAuthError(t *testing.T) {
+	auth := &http.BasicAuth{Username: "user", Password: "token"}
+
+	tests := []struct {
+		name string
+		err  error
+		auth transport.AuthMethod
+		want ErrorClass
+	}{
+		{"anonymous", transport.ErrAuthenticationRequired, nil, ErrorAuthRequired},
+		{"rejected credentials", transport.ErrAuthenticationRequired, auth, ErrorAuthFailed},
+		{"rejected ssh key", errors.New("ssh: handshake failed: ssh: unable to authenticate"), auth, ErrorAuthFailed},
+		{"other errors", transport.ErrRepositoryNotFound, auth, ErrorRepoNotFound},
+	}
+
+	for _, tt := range tests {
+		if got := Classify(wrapAuthError(tt.err, tt.auth)); got != tt.want {
+			t.Errorf("%s: Classify(wrapAuthError(%v)) = %q, want %q", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package git
+
+import (
+	"io/fs"
+	"path/filepath"
+)
+
+// FindRepositories returns every repository under root, clones and mirrors
+// alike, in lexical order. Repositories nested inside another one, such as
+// submodules, are not returned.
+func FindRepositories(root string) ([]string, error) {
+	var dirs []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if IsRepository(path) {
+			dirs = append(dirs, path)
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return dirs, err
+}
@@ -0,0 +1,87 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// LocalState describes what is on disk in a clone directory
+type LocalState struct {
+	Exists       bool      // the directory exists
+	IsRepository bool      // the directory holds a git repository
+	Bare         bool      // the repository has no worktree, as mirrors do
+	Head         string    // checked out branch, or commit when detached
+	Branches     int       // local branches, or remote branches for clones with a worktree
+	Tags         int       // tags
+	LastCommit   time.Time // committer date of HEAD
+}
+
+// Inspect returns the state of the clone in dir without touching the remote
+func Inspect(dir string) (*LocalState, error) {
+	state := &LocalState{}
+	if _, err := os.Stat(dir); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return state, nil
+		}
+		return nil, err
+	}
+	state.Exists = true
+
+	r, err := git.PlainOpen(dir)
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	state.IsRepository = true
+
+	if _, err := r.Worktree(); errors.Is(err, git.ErrIsBareRepository) {
+		state.Bare = true
+	}
+
+	// mirrors keep every branch under refs/heads, clones under the remote
+	refs, err := r.References()
+	if err != nil {
+		return nil, err
+	}
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		switch {
+		case ref.Type() != plumbing.HashReference:
+		case ref.Name().IsTag():
+			state.Tags++
+		case state.Bare && ref.Name().IsBranch(), !state.Bare && ref.Name().IsRemote():
+			state.Branches++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	head, err := r.Head()
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		// an empty repository
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	state.Head = head.Hash().String()[:7]
+	if head.Name().IsBranch() {
+		state.Head = head.Name().Short()
+	}
+	commit, err := r.CommitObject(head.Hash())
+	if err != nil && !errors.Is(err, plumbing.ErrObjectNotFound) {
+		return nil, err
+	}
+	if commit != nil {
+		state.LastCommit = commit.Committer.When
+	}
+
+	return state, nil
+}
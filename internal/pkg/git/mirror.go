@@ -0,0 +1,122 @@
+package git
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/dmaharana/clone-git-repo/internal/pkg/logger"
+	"github.com/dmaharana/clone-git-repo/internal/repostatus"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+const (
+	// mirrorRefSpec maps every ref of the source onto the same ref locally
+	mirrorRefSpec = "+refs/*:refs/*"
+)
+
+// MirrorRepo creates a bare mirror of a repository, including branches, tags,
+// notes and pull refs. If dir already holds a mirror, it is updated with a
+// pruning fetch instead. Mirrors always hold every ref with full history, so
+// the branch and depth in opts are ignored.
+func MirrorRepo(url string, dir string, rs *repostatus.RepoStatus, opts *Options) error {
+	cloneURL, auth, err := resolveAuth(url, opts)
+	if err != nil {
+		return err
+	}
+
+	var r *git.Repository
+	if IsRepository(dir) {
+		r, err = updateMirror(cloneURL, dir, auth, opts.Progress)
+	} else {
+		log.Info("Mirroring repository", logger.KeyDir, dir)
+		r, err = git.PlainClone(dir, true, &git.CloneOptions{
+			URL:      cloneURL,
+			Auth:     auth,
+			Mirror:   true,
+			Progress: opts.Progress,
+		})
+	}
+	if err != nil {
+		return wrapAuthError(err, auth)
+	}
+
+	branches, tags, err := countMirrorRefs(r)
+	if err != nil {
+		log.Warn("Failed to count refs", logger.KeyDir, dir, logger.KeyError, err)
+	}
+
+	log.Debug("Counted refs", logger.KeyDir, dir, "branches", branches, "tags", tags)
+
+	// update repo status
+	rs.IsCloned = true
+	rs.BranchCount = branches
+	rs.TagCount = tags
+
+	log.Info("Repository mirrored", logger.KeyDir, dir)
+
+	return nil
+}
+
+// updateMirror fetches every ref of an existing bare mirror, removing refs
+// that no longer exist upstream
+func updateMirror(url string, dir string, auth transport.AuthMethod, progress io.Writer) (*git.Repository, error) {
+	r, err := git.PlainOpen(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := r.Config()
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.Core.IsBare {
+		// a regular clone is in the way, let the caller replace it
+		return nil, git.ErrRepositoryAlreadyExists
+	}
+
+	log.Info("Updating mirror", logger.KeyDir, dir)
+	err = r.Fetch(&git.FetchOptions{
+		RemoteName: gitOrigin,
+		RemoteURL:  url,
+		RefSpecs:   []config.RefSpec{mirrorRefSpec},
+		Auth:       auth,
+		Progress:   progress,
+		Tags:       git.NoTags,
+		Prune:      true,
+		Force:      true,
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return nil, wrapAuthError(err, auth)
+	}
+
+	return r, nil
+}
+
+// countMirrorRefs counts the branches and tags held by a mirror
+func countMirrorRefs(r *git.Repository) (int, int, error) {
+	refs, err := r.References()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	branches, tags := 0, 0
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() != plumbing.HashReference {
+			return nil
+		}
+		name := ref.Name().String()
+		switch {
+		case strings.HasPrefix(name, "refs/heads/"):
+			branches++
+		case strings.HasPrefix(name, "refs/tags/"):
+			tags++
+		}
+		return nil
+	})
+
+	return branches, tags, err
+}
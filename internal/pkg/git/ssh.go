@@ -0,0 +1,171 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/dmaharana/clone-git-repo/internal/pkg/paths"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Host key policies
+const (
+	HostKeyStrict    = "strict"     // only hosts already in known_hosts
+	HostKeyAcceptNew = "accept-new" // add unknown hosts, reject changed keys
+	HostKeyInsecure  = "insecure"   // accept any host key
+)
+
+const (
+	defaultSSHUser = "git"
+)
+
+// ValidHostKeyPolicy reports whether policy is a known host key policy
+func ValidHostKeyPolicy(policy string) bool {
+	return policy == HostKeyStrict || policy == HostKeyAcceptNew || policy == HostKeyInsecure
+}
+
+var (
+	// serialises writes to known_hosts files from parallel clones
+	knownHostsMu sync.Mutex
+)
+
+// SSHOptions configures authentication over SSH
+type SSHOptions struct {
+	KeyFile       string // private key file, used when set
+	Passphrase    string // passphrase of the private key
+	UseAgent      bool   // authenticate through ssh-agent when no key file is set
+	KnownHosts    string // known_hosts file, empty for ~/.ssh/known_hosts
+	HostKeyPolicy string // strict (default), accept-new or insecure
+}
+
+// IsSSHURL reports whether url is an ssh:// or scp-style SSH URL
+func IsSSHURL(url string) bool {
+	u, err := paths.ParseURL(url)
+	return err == nil && u.IsSSH()
+}
+
+// sshUser returns the user name in an SSH URL, defaulting to git
+func sshUser(url string) string {
+	if u, err := paths.ParseURL(url); err == nil && u.User != "" {
+		return u.User
+	}
+	return defaultSSHUser
+}
+
+// sshAuth builds the SSH authentication method for url
+func sshAuth(url string, opts *SSHOptions) (transport.AuthMethod, error) {
+	if opts == nil {
+		opts = &SSHOptions{UseAgent: true}
+	}
+
+	callback, err := hostKeyCallback(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	user := sshUser(url)
+	switch {
+	case opts.KeyFile != "":
+		auth, err := ssh.NewPublicKeysFromFile(user, paths.ExpandHome(opts.KeyFile), opts.Passphrase)
+		if err != nil {
+			return nil, &Error{Class: ErrorAuthFailed, Err: fmt.Errorf("failed to load ssh key: %w", err)}
+		}
+		auth.HostKeyCallback = callback
+		return auth, nil
+	case opts.UseAgent:
+		auth, err := ssh.NewSSHAgentAuth(user)
+		if err != nil {
+			return nil, &Error{Class: ErrorAuthRequired, Err: fmt.Errorf("failed to use ssh-agent: %w", err)}
+		}
+		auth.HostKeyCallback = callback
+		return auth, nil
+	default:
+		return nil, &Error{Class: ErrorAuthRequired, Err: errors.New("no ssh key file configured and ssh-agent disabled")}
+	}
+}
+
+// hostKeyCallback verifies host keys according to the configured policy
+func hostKeyCallback(opts *SSHOptions) (gossh.HostKeyCallback, error) {
+	policy := opts.HostKeyPolicy
+	if policy == "" {
+		policy = HostKeyStrict
+	}
+	if policy == HostKeyInsecure {
+		return gossh.InsecureIgnoreHostKey(), nil
+	}
+
+	file := opts.KnownHosts
+	if file == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		file = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	file = paths.ExpandHome(file)
+
+	switch policy {
+	case HostKeyStrict:
+		callback, err := knownhosts.New(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read known hosts: %w", err)
+		}
+		return callback, nil
+	case HostKeyAcceptNew:
+		return acceptNewHostKeys(file)
+	default:
+		return nil, fmt.Errorf("unknown host key policy %q", policy)
+	}
+}
+
+// acceptNewHostKeys trusts hosts missing from the known_hosts file and
+// records their key, but still rejects hosts whose key has changed
+func acceptNewHostKeys(file string) (gossh.HostKeyCallback, error) {
+	knownHostsMu.Lock()
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		knownHostsMu.Unlock()
+		return nil, err
+	}
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_RDONLY, 0600)
+	if err == nil {
+		f.Close()
+	}
+	knownHostsMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(hostname string, remote net.Addr, key gossh.PublicKey) error {
+		knownHostsMu.Lock()
+		defer knownHostsMu.Unlock()
+
+		// reload so keys added by other clones are honoured
+		callback, err := knownhosts.New(file)
+		if err != nil {
+			return err
+		}
+
+		err = callback(hostname, remote, key)
+		var keyErr *knownhosts.KeyError
+		if !errors.As(err, &keyErr) || len(keyErr.Want) > 0 {
+			return err
+		}
+
+		f, err := os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		log.Info("Adding host key", "host", hostname, "known_hosts", file)
+		_, err = fmt.Fprintln(f, knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key))
+		return err
+	}, nil
+}
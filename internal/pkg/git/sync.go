@@ -0,0 +1,331 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dmaharana/clone-git-repo/internal/pkg/logger"
+	"github.com/dmaharana/clone-git-repo/internal/repostatus"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+const (
+	remoteBranchPrefix = "refs/remotes/" + gitOrigin + "/"
+)
+
+// IsRepository reports whether dir contains a git repository
+func IsRepository(dir string) bool {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		return true
+	}
+	// bare repositories keep HEAD at the top level
+	if _, err := os.Stat(filepath.Join(dir, "HEAD")); err == nil {
+		return true
+	}
+	return false
+}
+
+// SyncRepo fetches all branches and tags of origin into an existing clone,
+// fast-forwards the local branches and records what changed upstream. When
+// opts selects a branch, only that branch is fetched.
+func SyncRepo(url string, dir string, rs *repostatus.RepoStatus, opts *Options) error {
+	r, err := git.PlainOpen(dir)
+	if err != nil {
+		return err
+	}
+
+	remote, err := r.Remote(gitOrigin)
+	if err != nil {
+		return err
+	}
+
+	remoteURL := url
+	if urls := remote.Config().URLs; len(urls) > 0 {
+		remoteURL = urls[0]
+	}
+	fetchURL, auth, err := resolveAuth(remoteURL, opts)
+	if err != nil {
+		return err
+	}
+
+	before, err := remoteBranches(r)
+	if err != nil {
+		return err
+	}
+
+	branch := "*"
+	if opts.Branch != "" {
+		branch = opts.Branch
+	}
+
+	log.Info("Fetching updates", logger.KeyDir, dir)
+	err = r.Fetch(&git.FetchOptions{
+		RemoteName: gitOrigin,
+		RemoteURL:  fetchURL,
+		RefSpecs: []config.RefSpec{
+			config.RefSpec("+refs/heads/" + branch + ":" + remoteBranchPrefix + branch),
+		},
+		Depth:    opts.Depth,
+		Auth:     auth,
+		Progress: opts.Progress,
+		Tags:     git.AllTags,
+		Prune:    true,
+		Force:    true,
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return wrapAuthError(err, auth)
+	}
+
+	after, err := remoteBranches(r)
+	if err != nil {
+		return err
+	}
+
+	// work out what changed upstream
+	var newBranches, deletedBranches []string
+	var updated, created []plumbing.Hash
+	var ignore []plumbing.Hash
+	for name, hash := range before {
+		ignore = append(ignore, hash)
+		if _, ok := after[name]; !ok {
+			deletedBranches = append(deletedBranches, name)
+		}
+	}
+	for name, hash := range after {
+		old, ok := before[name]
+		if !ok {
+			newBranches = append(newBranches, name)
+			created = append(created, hash)
+			continue
+		}
+		if old != hash {
+			updated = append(updated, hash)
+		}
+	}
+	sort.Strings(newBranches)
+	sort.Strings(deletedBranches)
+
+	seen := make(map[plumbing.Hash]bool)
+	newCommits, err := countNewCommits(r, updated, ignore, seen)
+	if err == nil {
+		var n int
+		n, err = countNewBranchCommits(r, created, after, ignore, seen)
+		newCommits += n
+	}
+	if err != nil {
+		log.Warn("Failed to count new commits", logger.KeyDir, dir, logger.KeyError, err)
+	}
+
+	log.Debug("Fetched updates", logger.KeyDir, dir, "new_commits", newCommits, "new_branches", newBranches, "deleted_branches", deletedBranches)
+
+	if err := fastForwardBranches(r, after); err != nil {
+		log.Warn("Failed to fast-forward branches", logger.KeyDir, dir, logger.KeyError, err)
+	}
+
+	tList, err := findAllTags(r)
+	if err != nil {
+		log.Warn("Failed to list tags", logger.KeyDir, dir, logger.KeyError, err)
+	}
+
+	// update repo status
+	rs.IsCloned = true
+	rs.IsSynced = true
+	rs.BranchCount = len(after)
+	rs.TagCount = len(tList)
+	rs.NewCommits = newCommits
+	rs.NewBranches = newBranches
+	rs.DeletedBranches = deletedBranches
+
+	log.Info("Repository synced", logger.KeyDir, dir, "new_commits", newCommits)
+
+	return nil
+}
+
+// remoteBranches maps the short name of every origin branch to its hash
+func remoteBranches(r *git.Repository) (map[string]plumbing.Hash, error) {
+	refs, err := r.References()
+	if err != nil {
+		return nil, err
+	}
+
+	branches := make(map[string]plumbing.Hash)
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() != plumbing.HashReference {
+			return nil
+		}
+		name := ref.Name().String()
+		if strings.HasPrefix(name, remoteBranchPrefix) {
+			branches[strings.TrimPrefix(name, remoteBranchPrefix)] = ref.Hash()
+		}
+		return nil
+	})
+
+	return branches, err
+}
+
+// countNewCommits counts the commits reachable from tips but not from the
+// commits in ignore or in seen, adding those it counts to seen so history
+// shared between branches is counted once
+func countNewCommits(r *git.Repository, tips []plumbing.Hash, ignore []plumbing.Hash, seen map[plumbing.Hash]bool) (int, error) {
+	count := 0
+
+	for _, tip := range tips {
+		c, err := r.CommitObject(tip)
+		if err != nil {
+			return count, err
+		}
+
+		err = object.NewCommitPreorderIter(c, seen, ignore).ForEach(func(c *object.Commit) error {
+			if seen[c.Hash] {
+				return nil
+			}
+			seen[c.Hash] = true
+			count++
+			return nil
+		})
+		if err != nil && err != storer.ErrStop {
+			return count, err
+		}
+	}
+
+	return count, nil
+}
+
+// countNewBranchCommits counts the commits of new branches since they forked
+// off the default branch of origin, not the history they share with it
+func countNewBranchCommits(r *git.Repository, tips []plumbing.Hash, upstream map[string]plumbing.Hash,
+	ignore []plumbing.Hash, seen map[plumbing.Hash]bool) (int, error) {
+	if len(tips) == 0 {
+		return 0, nil
+	}
+
+	base, err := defaultBranchTip(r, upstream)
+	if err != nil {
+		return 0, err
+	}
+	baseCommit, err := r.CommitObject(base)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, tip := range tips {
+		c, err := r.CommitObject(tip)
+		if err != nil {
+			return count, err
+		}
+		forks, err := c.MergeBase(baseCommit)
+		if err != nil {
+			return count, err
+		}
+
+		// the fork points only bound the walk of their own branch
+		stop := append([]plumbing.Hash{}, ignore...)
+		for _, fork := range forks {
+			stop = append(stop, fork.Hash)
+		}
+		n, err := countNewCommits(r, []plumbing.Hash{tip}, stop, seen)
+		count += n
+		if err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+// defaultBranchTip returns the commit of origin's default branch, taken from
+// origin/HEAD or else the branch checked out locally
+func defaultBranchTip(r *git.Repository, upstream map[string]plumbing.Hash) (plumbing.Hash, error) {
+	if ref, err := r.Reference(plumbing.ReferenceName(remoteBranchPrefix+"HEAD"), true); err == nil {
+		return ref.Hash(), nil
+	}
+
+	head, err := r.Head()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if hash, ok := upstream[head.Name().Short()]; ok && head.Name().IsBranch() {
+		return hash, nil
+	}
+	return head.Hash(), nil
+}
+
+// fastForwardBranches moves every local branch to its upstream counterpart
+// when that is a fast-forward, and creates local branches for new upstream ones
+func fastForwardBranches(r *git.Repository, upstream map[string]plumbing.Hash) error {
+	head, err := r.Head()
+	if err != nil {
+		return err
+	}
+
+	for name, hash := range upstream {
+		if name == "HEAD" {
+			continue
+		}
+		branch := plumbing.NewBranchReferenceName(name)
+
+		local, err := r.Reference(branch, true)
+		if errors.Is(err, plumbing.ErrReferenceNotFound) {
+			// new upstream branch, track it locally like a fresh clone does
+			if err := r.Storer.SetReference(plumbing.NewHashReference(branch, hash)); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if local.Hash() == hash {
+			continue
+		}
+
+		ok, err := isFastForward(r, local.Hash(), hash)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			log.Warn("Branch has diverged from upstream, leaving it as is", "branch", name)
+			continue
+		}
+
+		if head.Name() == branch {
+			// update the checked out files along with the branch
+			w, err := r.Worktree()
+			if err != nil {
+				return err
+			}
+			err = w.Reset(&git.ResetOptions{Commit: hash, Mode: git.MergeReset})
+			if err != nil {
+				return fmt.Errorf("failed to fast-forward %s: %w", name, err)
+			}
+			continue
+		}
+
+		if err := r.Storer.SetReference(plumbing.NewHashReference(branch, hash)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isFastForward reports whether to is a descendant of from
+func isFastForward(r *git.Repository, from, to plumbing.Hash) (bool, error) {
+	fromCommit, err := r.CommitObject(from)
+	if err != nil {
+		return false, err
+	}
+	toCommit, err := r.CommitObject(to)
+	if err != nil {
+		return false, err
+	}
+	return fromCommit.IsAncestor(toCommit)
+}
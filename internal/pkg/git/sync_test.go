@@ -0,0 +1,126 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dmaharana/clone-git-repo/internal/repostatus"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// commitFile writes a file in the worktree of r and commits it
+func commitFile(t *testing.T, r *git.Repository, dir, name string) plumbing.Hash {
+	t.Helper()
+
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Add(name); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := w.Commit(name, &git.CommitOptions{
+		Author: &object.Signature{Name: "Test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+// setBranch points a branch of r at hash
+func setBranch(t *testing.T, r *git.Repository, name string, hash plumbing.Hash) {
+	t.Helper()
+	ref := plumbing.NewHashReference(plumbing.NewBranchReferenceName(name), hash)
+	if err := r.Storer.SetReference(ref); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSyncRepoCountsSharedCommitsOnce(t *testing.T) {
+	upstreamDir := filepath.Join(t.TempDir(), "upstream")
+	upstream, err := git.PlainInit(upstreamDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := commitFile(t, upstream, upstreamDir, "base")
+	setBranch(t, upstream, "dev", base)
+
+	cloneDir := filepath.Join(t.TempDir(), "clone")
+	if err := CloneRepo(upstreamDir, cloneDir, &repostatus.RepoStatus{}, &Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// three new commits reachable from two existing branches and from a
+	// new one
+	var tip plumbing.Hash
+	for _, name := range []string{"one", "two", "three"} {
+		tip = commitFile(t, upstream, upstreamDir, name)
+	}
+	setBranch(t, upstream, "dev", tip)
+	setBranch(t, upstream, "feature", tip)
+
+	rs := &repostatus.RepoStatus{}
+	if err := SyncRepo(upstreamDir, cloneDir, rs, &Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if rs.NewCommits != 3 {
+		t.Errorf("NewCommits = %d, want 3", rs.NewCommits)
+	}
+	if len(rs.NewBranches) != 1 || rs.NewBranches[0] != "feature" {
+		t.Errorf("NewBranches = %v, want [feature]", rs.NewBranches)
+	}
+}
+
+func TestSyncRepoCountsNewBranchFromForkPoint(t *testing.T) {
+	upstreamDir := filepath.Join(t.TempDir(), "upstream")
+	upstream, err := git.PlainInit(upstreamDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, upstream, upstreamDir, "base")
+	fork := commitFile(t, upstream, upstreamDir, "fork")
+
+	cloneDir := filepath.Join(t.TempDir(), "clone")
+	if err := CloneRepo(upstreamDir, cloneDir, &repostatus.RepoStatus{}, &Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// two commits on a new branch off the fork point, one on the default
+	// branch
+	head, err := upstream.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := upstream.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	branch := plumbing.NewBranchReferenceName("feature")
+	if err := w.Checkout(&git.CheckoutOptions{Hash: fork, Branch: branch, Create: true}); err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, upstream, upstreamDir, "feature-one")
+	commitFile(t, upstream, upstreamDir, "feature-two")
+	if err := w.Checkout(&git.CheckoutOptions{Branch: head.Name()}); err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, upstream, upstreamDir, "main")
+
+	rs := &repostatus.RepoStatus{}
+	if err := SyncRepo(upstreamDir, cloneDir, rs, &Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if rs.NewCommits != 3 {
+		t.Errorf("NewCommits = %d, want 3", rs.NewCommits)
+	}
+}
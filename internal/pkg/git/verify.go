@@ -0,0 +1,36 @@
+package git
+
+import (
+	"errors"
+
+	"github.com/dmaharana/clone-git-repo/internal/pkg/logger"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// VerifyRemote checks that the repository at url can be reached with the
+// credentials in opts by listing its refs, and returns the number of refs
+func VerifyRemote(url string, opts *Options) (int, error) {
+	remoteURL, auth, err := resolveAuth(url, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: gitOrigin,
+		URLs: []string{remoteURL},
+	})
+	refs, err := remote.List(&git.ListOptions{Auth: auth})
+	if errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		// reachable, there is just nothing to clone yet
+		return 0, nil
+	}
+	if err != nil {
+		return 0, wrapAuthError(err, auth)
+	}
+
+	log.Debug("Remote verified", logger.KeyRepo, url, "refs", len(refs))
+	return len(refs), nil
+}
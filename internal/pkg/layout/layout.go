@@ -0,0 +1,155 @@
+package layout
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/dmaharana/clone-git-repo/internal/pkg/paths"
+)
+
+// Named layouts that can be used in place of a template
+var presets = map[string]string{
+	"flat":  "{repo}",
+	"owner": "{owner}__{repo}",
+	"host":  "{host}/{owner}/{repo}",
+}
+
+const (
+	// DefaultLayout keeps every repository directly under the clone directory
+	DefaultLayout = "flat"
+
+	// ownerSeparator joins the groups of an owner that shares a directory
+	// name with other placeholders
+	ownerSeparator = "__"
+)
+
+var (
+	placeholderRegex = regexp.MustCompile(`\{([a-z]+)\}`)
+
+	// characters Windows and most file systems refuse in a file name
+	unsafeRegex = regexp.MustCompile(`[<>:"\\|?*\x00-\x1f]`)
+)
+
+// Layout maps repository URLs to directories using a template such as
+// {host}/{owner}/{repo}
+type Layout struct {
+	template string
+}
+
+// New creates a layout from a template or the name of a preset layout
+func New(template string) (*Layout, error) {
+	if template == "" {
+		template = DefaultLayout
+	}
+	if preset, ok := presets[template]; ok {
+		template = preset
+	}
+
+	for _, m := range placeholderRegex.FindAllStringSubmatch(template, -1) {
+		switch m[1] {
+		case "host", "owner", "repo":
+		default:
+			return nil, fmt.Errorf("unknown placeholder %s in layout %q", m[0], template)
+		}
+	}
+	if !strings.Contains(template, "{repo}") {
+		return nil, fmt.Errorf("layout %q must contain {repo}", template)
+	}
+
+	return &Layout{template: template}, nil
+}
+
+// Dir returns the directory, relative to the clone directory, for a repository URL
+func (l *Layout) Dir(rawURL string) (string, error) {
+	u, err := paths.ParseURL(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	// an owner that fills a whole segment keeps nested groups as nested
+	// directories, one sharing its segment with other text, as in the owner
+	// preset, has them joined with __ so the layout stays flat
+	segments := strings.Split(l.template, "/")
+	for i, segment := range segments {
+		owner := u.Owner
+		if segment != "{owner}" {
+			owner = strings.ReplaceAll(owner, "/", ownerSeparator)
+		}
+		values := map[string]string{"{host}": u.Host, "{owner}": owner, "{repo}": u.Repo}
+
+		// a missing part takes its separator along, e.g. no owner for a
+		// local path in the owner preset
+		for p, value := range values {
+			if value == "" {
+				segment = strings.ReplaceAll(segment, p+ownerSeparator, "")
+				segment = strings.ReplaceAll(segment, ownerSeparator+p, "")
+			}
+		}
+		segments[i] = placeholderRegex.ReplaceAllStringFunc(segment, func(p string) string {
+			return sanitize(values[p])
+		})
+	}
+	dir := strings.Join(segments, "/")
+
+	// drop empty segments left by missing parts, e.g. no host for local paths
+	var parts []string
+	for _, part := range strings.Split(dir, "/") {
+		if part == "" || part == "." {
+			continue
+		}
+		if part == ".." {
+			return "", fmt.Errorf("layout for %q leaves the clone directory", rawURL)
+		}
+		parts = append(parts, part)
+	}
+	if len(parts) == 0 {
+		return "", fmt.Errorf("layout for %q produces an empty directory", rawURL)
+	}
+
+	return filepath.Join(parts...), nil
+}
+
+// sanitize replaces the characters that are not allowed in file names on
+// common file systems, keeping everything else as it is. Slashes are kept, as
+// they separate nested owners.
+func sanitize(name string) string {
+	return unsafeRegex.ReplaceAllString(name, "_")
+}
+
+// CheckCollisions fails if two of the repositories share a directory, or if
+// one would be cloned inside the directory of another. dirs and urls are
+// parallel slices. Directories are compared case-insensitively so the check
+// is safe on every file system.
+func CheckCollisions(dirs []string, urls []string) error {
+	owners := make(map[string][]string)
+	for i, dir := range dirs {
+		key := strings.ToLower(filepath.ToSlash(filepath.Clean(dir)))
+		owners[key] = append(owners[key], urls[i])
+	}
+
+	var collisions []string
+	for key, urls := range owners {
+		if len(urls) > 1 {
+			collisions = append(collisions, fmt.Sprintf("%s <- %s", key, strings.Join(urls, ", ")))
+		}
+
+		// a clone inside another one's worktree is hidden from scans that
+		// stop at the outer repository
+		for parent := path.Dir(key); parent != "." && parent != "/"; parent = path.Dir(parent) {
+			if outer, ok := owners[parent]; ok {
+				collisions = append(collisions, fmt.Sprintf("%s <- %s is inside %s <- %s",
+					key, strings.Join(urls, ", "), parent, strings.Join(outer, ", ")))
+			}
+		}
+	}
+	if len(collisions) > 0 {
+		sort.Strings(collisions)
+		return fmt.Errorf("repositories collide in the clone directory:\n  %s", strings.Join(collisions, "\n  "))
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package layout
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckCollisions(t *testing.T) {
+	tests := []struct {
+		name    string
+		dirs    []string
+		wantErr string
+	}{
+		{
+			name: "distinct",
+			dirs: []string{"g/a", "g/b", "h/a"},
+		},
+		{
+			name: "siblings sharing a prefix",
+			dirs: []string{"g/a", "g/ab", "g/a-b"},
+		},
+		{
+			name:    "equal",
+			dirs:    []string{"g/a", "g/a"},
+			wantErr: "g/a <- url0, url1",
+		},
+		{
+			name:    "equal after cleaning and case folding",
+			dirs:    []string{"G/A/", "g/./a"},
+			wantErr: "g/a <- url0, url1",
+		},
+		{
+			name:    "nested",
+			dirs:    []string{"g/a", "g/a/b"},
+			wantErr: "g/a/b <- url1 is inside g/a <- url0",
+		},
+		{
+			name:    "nested several levels deep",
+			dirs:    []string{"g/a/b/c", "G"},
+			wantErr: "g/a/b/c <- url0 is inside g <- url1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			urls := make([]string, len(tt.dirs))
+			for i := range urls {
+				urls[i] = fmt.Sprintf("url%d", i)
+			}
+
+			err := CheckCollisions(tt.dirs, urls)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("CheckCollisions(%v) = %v, want nil", tt.dirs, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("CheckCollisions(%v) = %v, want error containing %q", tt.dirs, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNestedOwnerCollides(t *testing.T) {
+	l, err := New("{owner}/{repo}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	urls := []string{"https://example.com/g/a.git", "https://example.com/g/a/b.git"}
+	dirs := make([]string, len(urls))
+	for i, rawURL := range urls {
+		if dirs[i], err = l.Dir(rawURL); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := CheckCollisions(dirs, urls); err == nil {
+		t.Fatalf("CheckCollisions(%v) = nil, want a collision error", dirs)
+	}
+}
+
+func TestDir(t *testing.T) {
+	tests := []struct {
+		layout string
+		url    string
+		want   string
+	}{
+		{"flat", "git@gitlab.com:g/sub/a.git", "a"},
+		{"owner", "git@gitlab.com:team/utils.git", "team__utils"},
+		{"owner", "git@gitlab.com:g/sub/a.git", "g__sub__a"},
+		{"host", "git@gitlab.com:g/sub/a.git", filepath.Join("gitlab.com", "g", "sub", "a")},
+		{"{owner}-{repo}", "https://gitlab.com/g/sub/a", "g__sub-a"},
+		{"owner", "/srv/git/a.git", "srv__git__a"},
+		{"owner", "a.git", "a"},
+		{"{host}__{repo}", "a.git", "a"},
+		{"flat", "https://example.com/g/__init__.git", "__init__"},
+		{"owner", "https://example.com/__g__/a__.git", "__g____a__"},
+		{"flat", "https://example.com/g/a%3Ab%3F.git", "a_b_"},
+	}
+
+	for _, tt := range tests {
+		l, err := New(tt.layout)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := l.Dir(tt.url)
+		if err != nil {
+			t.Errorf("Dir(%q) with layout %s: %v", tt.url, tt.layout, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Dir(%q) with layout %s = %q, want %q", tt.url, tt.layout, got, tt.want)
+		}
+	}
+}
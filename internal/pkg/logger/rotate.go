@@ -0,0 +1,263 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// logFilePrefix starts the name of every log file, active or rotated
+	logFilePrefix = "clone-git-repo-"
+
+	// backupTimeFormat is appended to the name of rotated log files
+	backupTimeFormat = "20060102T150405.000"
+)
+
+// rotatingFile is a log file that is rotated once it would grow beyond
+// maxSize and when the date in its name changes. It is safe for concurrent
+// use.
+type rotatingFile struct {
+	cfg *Config
+	now func() time.Time // clock deciding the date and the age of backups
+
+	mu   sync.Mutex
+	file *os.File
+	path string
+	date string
+	size int64
+
+	// compression and pruning of rotated files run in the background, one
+	// at a time, without blocking writers
+	cleanupMu sync.Mutex
+	wg        sync.WaitGroup
+}
+
+// openRotatingFile opens the log file for today, creating the log directory
+// if needed
+func openRotatingFile(cfg *Config) (*rotatingFile, error) {
+	return openRotatingFileAt(cfg, time.Now)
+}
+
+// openRotatingFileAt opens the log file for the date given by now
+func openRotatingFileAt(cfg *Config, now func() time.Time) (*rotatingFile, error) {
+	if err := os.MkdirAll(cfg.LogDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %v", err)
+	}
+
+	r := &rotatingFile{cfg: cfg, now: now}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	r.cleanup()
+	return r, nil
+}
+
+// Write implements io.Writer
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	dateChanged := r.now().Format(r.cfg.TimeFormat) != r.date
+	tooLarge := r.cfg.MaxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.cfg.MaxSize
+	if dateChanged || tooLarge {
+		if err := r.rotate(tooLarge && !dateChanged); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Close closes the log file and waits for background compression to finish
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	var err error
+	if r.file != nil {
+		err = r.file.Close()
+		r.file = nil
+	}
+	r.mu.Unlock()
+
+	r.wg.Wait()
+	return err
+}
+
+// open opens the log file named after the current date for appending
+func (r *rotatingFile) open() error {
+	r.date = r.now().Format(r.cfg.TimeFormat)
+	r.path = filepath.Join(r.cfg.LogDir, logFilePrefix+r.date+".log")
+
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to open log file: %v", err)
+	}
+
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+// rotate closes the current file, renames it to a timestamped backup when it
+// is full, and opens a fresh one. Must be called with mu held.
+func (r *rotatingFile) rotate(full bool) error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	r.file = nil
+
+	if full {
+		base := strings.TrimSuffix(r.path, ".log") + "." + r.now().Format(backupTimeFormat)
+		backup := base + ".log"
+		for i := 1; fileExists(backup) || fileExists(backup+".gz"); i++ {
+			backup = fmt.Sprintf("%s-%d.log", base, i)
+		}
+		if err := os.Rename(r.path, backup); err != nil {
+			return fmt.Errorf("failed to rotate log file: %v", err)
+		}
+	}
+
+	if err := r.open(); err != nil {
+		return err
+	}
+	r.cleanup()
+	return nil
+}
+
+// cleanup compresses rotated files and prunes old ones in the background
+func (r *rotatingFile) cleanup() {
+	if !r.cfg.Compress && r.cfg.MaxBackups <= 0 && r.cfg.MaxAge <= 0 {
+		return
+	}
+
+	active := r.path
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.cleanupMu.Lock()
+		defer r.cleanupMu.Unlock()
+
+		if r.cfg.Compress {
+			r.compressBackups(active)
+		}
+		r.pruneBackups(active)
+	}()
+}
+
+// backups returns the rotated log files, newest first: those rotated for
+// size as well as the logs of earlier days
+func (r *rotatingFile) backups(active string) []os.FileInfo {
+	entries, err := os.ReadDir(r.cfg.LogDir)
+	if err != nil {
+		return nil
+	}
+
+	var files []os.FileInfo
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, logFilePrefix) ||
+			!(strings.HasSuffix(name, ".log") || strings.HasSuffix(name, ".log.gz")) ||
+			name == filepath.Base(active) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, info)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().After(files[j].ModTime())
+	})
+	return files
+}
+
+// compressBackups gzips rotated files that are not compressed yet
+func (r *rotatingFile) compressBackups(active string) {
+	for _, info := range r.backups(active) {
+		if strings.HasSuffix(info.Name(), ".gz") {
+			continue
+		}
+		path := filepath.Join(r.cfg.LogDir, info.Name())
+		if err := compressFile(path); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to compress log file %s: %v\n", path, err)
+		}
+	}
+}
+
+// pruneBackups removes rotated files beyond MaxBackups or older than MaxAge
+func (r *rotatingFile) pruneBackups(active string) {
+	cutoff := r.now().Add(-r.cfg.MaxAge)
+	for i, info := range r.backups(active) {
+		tooMany := r.cfg.MaxBackups > 0 && i >= r.cfg.MaxBackups
+		tooOld := r.cfg.MaxAge > 0 && info.ModTime().Before(cutoff)
+		if !tooMany && !tooOld {
+			continue
+		}
+		path := filepath.Join(r.cfg.LogDir, info.Name())
+		if err := os.Remove(path); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to remove log file %s: %v\n", path, err)
+		}
+	}
+}
+
+// compressFile replaces path with a gzipped copy, keeping its modification
+// time so pruning by age still works
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	_, err = io.Copy(gz, src)
+	if closeErr := gz.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+
+	os.Chtimes(path+".gz", info.ModTime(), info.ModTime())
+	src.Close()
+	return os.Remove(path)
+}
+
+// fileExists reports whether path exists
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
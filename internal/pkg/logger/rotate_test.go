@@ -0,0 +1,173 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock that only moves when told to
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+// logFiles returns the names of the files in dir, sorted
+func logFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestRotatingFileRotates(t *testing.T) {
+	dir := t.TempDir()
+	clock := &fakeClock{t: time.Date(2024, 5, 1, 10, 15, 0, 0, time.UTC)}
+	r, err := openRotatingFileAt(&Config{LogDir: dir, MaxSize: 10, TimeFormat: "2006-01-02"}, clock.now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write := func(s string) {
+		t.Helper()
+		if _, err := r.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("12345678\n")
+	// would grow beyond 10 bytes, twice within the same millisecond
+	write("12345678\n")
+	write("12345678\n")
+	// a new day starts a new file without renaming the old one
+	clock.t = clock.t.Add(24 * time.Hour)
+	write("next day\n")
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"clone-git-repo-2024-05-01.20240501T101500.000-1.log",
+		"clone-git-repo-2024-05-01.20240501T101500.000.log",
+		"clone-git-repo-2024-05-01.log",
+		"clone-git-repo-2024-05-02.log",
+	}
+	if got := logFiles(t, dir); !reflect.DeepEqual(got, want) {
+		t.Errorf("log files = %v, want %v", got, want)
+	}
+}
+
+func TestRotatingFilePrunes(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	// existing files and their age, both daily and size-rotated
+	existing := []struct {
+		name string
+		age  time.Duration
+	}{
+		{"clone-git-repo-2024-05-10.log", 30 * day}, // active, never pruned
+		{"clone-git-repo-2024-05-09.log", 1 * day},
+		{"clone-git-repo-2024-05-08.20240508T090000.000.log.gz", 2 * day},
+		{"clone-git-repo-2024-05-08.log", 2*day + time.Hour},
+		{"clone-git-repo-2024-04-01.log", 39 * day},
+		{"clone-git-repo-2024-04-01.20240401T090000.000.log", 39*day + time.Hour},
+		{"notes.txt", 100 * day},
+	}
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want []string
+	}{
+		{
+			name: "keep all",
+			cfg:  Config{},
+			want: []string{
+				"clone-git-repo-2024-04-01.20240401T090000.000.log",
+				"clone-git-repo-2024-04-01.log",
+				"clone-git-repo-2024-05-08.20240508T090000.000.log.gz",
+				"clone-git-repo-2024-05-08.log",
+				"clone-git-repo-2024-05-09.log",
+				"clone-git-repo-2024-05-10.log",
+				"notes.txt",
+			},
+		},
+		{
+			name: "max backups",
+			cfg:  Config{MaxBackups: 2},
+			want: []string{
+				"clone-git-repo-2024-05-08.20240508T090000.000.log.gz",
+				"clone-git-repo-2024-05-09.log",
+				"clone-git-repo-2024-05-10.log",
+				"notes.txt",
+			},
+		},
+		{
+			name: "max age",
+			cfg:  Config{MaxAge: 7 * day},
+			want: []string{
+				"clone-git-repo-2024-05-08.20240508T090000.000.log.gz",
+				"clone-git-repo-2024-05-08.log",
+				"clone-git-repo-2024-05-09.log",
+				"clone-git-repo-2024-05-10.log",
+				"notes.txt",
+			},
+		},
+		{
+			name: "compress and max age",
+			cfg:  Config{MaxAge: 7 * day, Compress: true},
+			want: []string{
+				"clone-git-repo-2024-05-08.20240508T090000.000.log.gz",
+				"clone-git-repo-2024-05-08.log.gz",
+				"clone-git-repo-2024-05-09.log.gz",
+				"clone-git-repo-2024-05-10.log",
+				"notes.txt",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, file := range existing {
+				path := filepath.Join(dir, file.name)
+				if err := os.WriteFile(path, []byte("old\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+				mtime := now.Add(-file.age)
+				if err := os.Chtimes(path, mtime, mtime); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			cfg := tt.cfg
+			cfg.LogDir = dir
+			cfg.TimeFormat = "2006-01-02"
+			r, err := openRotatingFileAt(&cfg, func() time.Time { return now })
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := r.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := logFiles(t, dir); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("log files = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
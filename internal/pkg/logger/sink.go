@@ -0,0 +1,215 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Log outputs
+const (
+	OutputFile   = "file"   // the log file only
+	OutputStderr = "stderr" // the console only
+	OutputBoth   = "both"   // the log file and the console
+)
+
+// ANSI colors of the console levels
+const (
+	colorReset  = "\033[0m"
+	colorGray   = "\033[90m"
+	colorBlue   = "\033[34m"
+	colorYellow = "\033[33m"
+	colorRed    = "\033[31m"
+)
+
+// ValidOutput reports whether output is a known log output
+func ValidOutput(output string) bool {
+	return output == OutputFile || output == OutputStderr || output == OutputBoth
+}
+
+// isTerminal reports whether f is a terminal that accepts colors. NO_COLOR
+// disables colors, see https://no-color.org.
+func isTerminal(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// fanoutHandler passes records on to every handler that accepts their level
+type fanoutHandler []slog.Handler
+
+func (h fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h fanoutHandler) Handle(ctx context.Context, r slog.Record) error {
+	var firstErr error
+	for _, handler := range h {
+		if !handler.Enabled(ctx, r.Level) {
+			continue
+		}
+		if err := handler.Handle(ctx, r.Clone()); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (h fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(fanoutHandler, len(h))
+	for i, handler := range h {
+		handlers[i] = handler.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (h fanoutHandler) WithGroup(name string) slog.Handler {
+	handlers := make(fanoutHandler, len(h))
+	for i, handler := range h {
+		handlers[i] = handler.WithGroup(name)
+	}
+	return handlers
+}
+
+// consoleHandler writes compact, optionally colored records for people
+// watching the terminal: time, level, message and the fields as key=value
+type consoleHandler struct {
+	w      io.Writer
+	mu     *sync.Mutex
+	level  slog.Level
+	color  bool
+	prefix string // group prefix for attribute keys
+	attrs  []byte // preformatted attributes added with WithAttrs
+}
+
+// newConsoleHandler creates a console handler writing to w
+func newConsoleHandler(w io.Writer, level slog.Level, color bool) *consoleHandler {
+	return &consoleHandler{w: w, mu: &sync.Mutex{}, level: level, color: color}
+}
+
+func (h *consoleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *consoleHandler) Handle(_ context.Context, r slog.Record) error {
+	var buf bytes.Buffer
+
+	buf.WriteString(r.Time.Format(time.TimeOnly))
+	buf.WriteByte(' ')
+	h.writeLevel(&buf, r.Level)
+	buf.WriteByte(' ')
+	buf.WriteString(r.Message)
+	buf.Write(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		h.appendAttr(&buf, h.prefix, a)
+		return true
+	})
+	buf.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(buf.Bytes())
+	return err
+}
+
+func (h *consoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var buf bytes.Buffer
+	buf.Write(h.attrs)
+	for _, a := range attrs {
+		h.appendAttr(&buf, h.prefix, a)
+	}
+
+	clone := *h
+	clone.attrs = buf.Bytes()
+	return &clone
+}
+
+func (h *consoleHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.prefix = h.prefix + name + "."
+	return &clone
+}
+
+// writeLevel writes the level padded to a fixed width
+func (h *consoleHandler) writeLevel(buf *bytes.Buffer, level slog.Level) {
+	name := fmt.Sprintf("%-5s", level.String())
+	if !h.color {
+		buf.WriteString(name)
+		return
+	}
+
+	color := colorBlue
+	switch {
+	case level < LevelInfo:
+		color = colorGray
+	case level >= LevelError:
+		color = colorRed
+	case level >= LevelWarn:
+		color = colorYellow
+	}
+	buf.WriteString(color + name + colorReset)
+}
+
+// appendAttr writes a single attribute as key=value, quoting values that
+// contain spaces
+func (h *consoleHandler) appendAttr(buf *bytes.Buffer, prefix string, a slog.Attr) {
+	a = replaceAttr(nil, a)
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		for _, member := range a.Value.Group() {
+			h.appendAttr(buf, prefix+a.Key+".", member)
+		}
+		return
+	}
+
+	buf.WriteByte(' ')
+	if h.color {
+		buf.WriteString(colorGray + prefix + a.Key + "=" + colorReset)
+	} else {
+		buf.WriteString(prefix + a.Key + "=")
+	}
+
+	value := fmt.Sprint(a.Value.Any())
+	if a.Value.Kind() == slog.KindString {
+		value = a.Value.String()
+	}
+	if needsQuoting(value) {
+		value = strconv.Quote(value)
+	}
+	buf.WriteString(value)
+}
+
+// needsQuoting reports whether a console value must be quoted to stay on
+// one line and be told apart from the next field
+func needsQuoting(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if unicode.IsSpace(r) || r == '"' || r == '=' || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
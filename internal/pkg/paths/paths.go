@@ -0,0 +1,89 @@
+package paths
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	// scp-like SSH syntax: [user@]host:path
+	scpRegex = regexp.MustCompile(`^(?:([^@/]+)@)?([^:/]+):(.+)$`)
+)
+
+// RepoURL holds the parts of a repository URL used by layouts and
+// authentication
+type RepoURL struct {
+	Scheme string // lower-cased URL scheme, ssh for scp-style URLs, empty for local paths
+	User   string // user name in the URL, if any
+	Host   string // host name without port, empty for local paths
+	Owner  string // everything between host and repository, may contain slashes
+	Repo   string // repository name without the .git suffix
+}
+
+// IsSSH reports whether the URL is an ssh:// or scp-style SSH URL
+func (u *RepoURL) IsSSH() bool {
+	return u.Scheme == "ssh"
+}
+
+// ParseURL splits a repository URL into scheme, user, host, owner and
+// repository name. It understands http(s), ssh and git URLs, scp-style SSH
+// and local paths, and is the one place repository URLs are taken apart.
+func ParseURL(rawURL string) (*RepoURL, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return nil, fmt.Errorf("empty repository URL")
+	}
+
+	var scheme, user, host, repoPath string
+	switch {
+	case strings.Contains(rawURL, "://"):
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid repository URL %q: %w", rawURL, err)
+		}
+		scheme = strings.ToLower(u.Scheme)
+		if u.User != nil {
+			user = u.User.Username()
+		}
+		host = u.Hostname()
+		repoPath = u.Path
+	case scpRegex.MatchString(rawURL) && !filepath.IsAbs(rawURL):
+		m := scpRegex.FindStringSubmatch(rawURL)
+		scheme = "ssh"
+		user = m[1]
+		host = m[2]
+		repoPath = m[3]
+	default:
+		repoPath = filepath.ToSlash(rawURL)
+	}
+
+	repoPath = strings.Trim(path.Clean("/"+repoPath), "/")
+	repoPath = strings.TrimSuffix(repoPath, ".git")
+	if repoPath == "" || repoPath == "." {
+		return nil, fmt.Errorf("no repository path in URL %q", rawURL)
+	}
+
+	owner, repo := path.Split(repoPath)
+	return &RepoURL{
+		Scheme: scheme,
+		User:   user,
+		Host:   strings.ToLower(host),
+		Owner:  strings.Trim(owner, "/"),
+		Repo:   repo,
+	}, nil
+}
+
+// ExpandHome replaces a leading ~ with the home directory
+func ExpandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, strings.TrimPrefix(path, "~"))
+		}
+	}
+	return path
+}
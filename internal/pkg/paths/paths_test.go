@@ -0,0 +1,30 @@
+package paths
+
+import (
+	"testing"
+)
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want RepoURL
+		ssh  bool
+	}{
+		{"https://github.com/acme/tool.git", RepoURL{Scheme: "https", Host: "github.com", Owner: "acme", Repo: "tool"}, false},
+		{"ssh://deploy@Git.Example.com:2222/g/sub/tool", RepoURL{Scheme: "ssh", User: "deploy", Host: "git.example.com", Owner: "g/sub", Repo: "tool"}, true},
+		{"git@gitlab.com:team/utils.git", RepoURL{Scheme: "ssh", User: "git", Host: "gitlab.com", Owner: "team", Repo: "utils"}, true},
+		{"gitlab.com:team/utils.git", RepoURL{Scheme: "ssh", Host: "gitlab.com", Owner: "team", Repo: "utils"}, true},
+		{"/srv/git/tool.git", RepoURL{Owner: "srv/git", Repo: "tool"}, false},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseURL(tt.url)
+		if err != nil {
+			t.Errorf("ParseURL(%q): %v", tt.url, err)
+			continue
+		}
+		if *got != tt.want || got.IsSSH() != tt.ssh {
+			t.Errorf("ParseURL(%q) = %+v (ssh %t), want %+v (ssh %t)", tt.url, *got, got.IsSSH(), tt.want, tt.ssh)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package retry
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Policy describes how failed operations are retried
+type Policy struct {
+	MaxAttempts int           // total attempts, including the first one
+	BaseDelay   time.Duration // delay before the first retry
+	MaxDelay    time.Duration // upper bound for any delay
+	Jitter      float64       // fraction of the delay randomly added or removed, 0 to 1
+	Retryable   []string      // error classes worth retrying
+}
+
+// DefaultPolicy returns the default retry policy
+func DefaultPolicy() *Policy {
+	return &Policy{
+		MaxAttempts: 4,
+		BaseDelay:   2 * time.Second,
+		MaxDelay:    time.Minute,
+		Jitter:      0.2,
+		Retryable:   []string{"network", "server_error"},
+	}
+}
+
+// IsRetryable reports whether errors of the given class should be retried
+func (p *Policy) IsRetryable(class string) bool {
+	for _, c := range p.Retryable {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// Delay returns how long to wait after the given failed attempt, starting at
+// 1. The delay doubles with every attempt up to MaxDelay, with jitter applied.
+// A MaxDelay of 0 means no cap, though the delay never overflows.
+func (p *Policy) Delay(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	limit := float64(math.MaxInt64)
+	if p.MaxDelay > 0 {
+		limit = float64(p.MaxDelay)
+	}
+
+	// beyond 2^62 every delay is past the limit anyway
+	exponent := math.Min(float64(attempt-1), 62)
+	delay := math.Min(float64(p.BaseDelay)*math.Pow(2, exponent), limit)
+
+	if p.Jitter > 0 {
+		jitter := math.Min(p.Jitter, 1)
+		delay += delay * jitter * (2*rand.Float64() - 1)
+		delay = math.Min(delay, limit)
+	}
+
+	// float64(math.MaxInt64) rounds up to 2^63, which no Duration holds
+	if delay >= float64(math.MaxInt64) {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(delay)
+}
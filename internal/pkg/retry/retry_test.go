@@ -0,0 +1,82 @@
+package retry
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  Policy
+		attempt int
+		want    time.Duration
+	}{
+		{"first retry", Policy{BaseDelay: time.Second}, 1, time.Second},
+		{"attempt below one", Policy{BaseDelay: time.Second}, 0, time.Second},
+		{"doubles", Policy{BaseDelay: time.Second}, 4, 8 * time.Second},
+		{"capped", Policy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}, 4, 5 * time.Second},
+		{"no cap does not overflow", Policy{BaseDelay: time.Second}, 2000, time.Duration(math.MaxInt64)},
+		{"cap holds for huge attempts", Policy{BaseDelay: time.Second, MaxDelay: time.Minute}, 2000, time.Minute},
+		{"jitter does not overflow", Policy{BaseDelay: time.Second, Jitter: 1}, 2000, -1},
+	}
+
+	for _, tt := range tests {
+		got := tt.policy.Delay(tt.attempt)
+		if got < 0 {
+			t.Errorf("%s: Delay(%d) = %v, want a positive delay", tt.name, tt.attempt, got)
+			continue
+		}
+		if tt.want >= 0 && got != tt.want {
+			t.Errorf("%s: Delay(%d) = %v, want %v", tt.name, tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestDelayJitter(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   Policy
+		attempt  int
+		min, max time.Duration
+	}{
+		{"within the fraction", Policy{BaseDelay: time.Second, Jitter: 0.2}, 2, 1600 * time.Millisecond, 2400 * time.Millisecond},
+		{"fraction above one is one", Policy{BaseDelay: time.Second, Jitter: 5}, 1, 0, 2 * time.Second},
+		{"never above the cap", Policy{BaseDelay: time.Second, MaxDelay: 3 * time.Second, Jitter: 0.5}, 3, 1500 * time.Millisecond, 3 * time.Second},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 1000; i++ {
+			if got := tt.policy.Delay(tt.attempt); got < tt.min || got > tt.max {
+				t.Fatalf("%s: Delay(%d) = %v, want between %v and %v", tt.name, tt.attempt, got, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		class string
+		want  bool
+	}{
+		{"network", true},
+		{"server_error", true},
+		{"auth_failed", false},
+		{"repo_not_found", false},
+		{"already_exists", false},
+		{"", false},
+	}
+
+	policy := DefaultPolicy()
+	for _, tt := range tests {
+		if got := policy.IsRetryable(tt.class); got != tt.want {
+			t.Errorf("IsRetryable(%q) = %t, want %t", tt.class, got, tt.want)
+		}
+	}
+
+	custom := &Policy{Retryable: []string{"unknown"}}
+	if !custom.IsRetryable("unknown") || custom.IsRetryable("network") {
+		t.Errorf("IsRetryable does not follow the configured classes %v", custom.Retryable)
+	}
+}
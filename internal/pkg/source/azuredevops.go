@@ -0,0 +1,128 @@
+package source
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/dmaharana/clone-git-repo/internal/pkg/config"
+	"github.com/dmaharana/clone-git-repo/internal/pkg/csv"
+)
+
+const (
+	// DefaultAzureDevOpsURL is Azure DevOps Services. Azure DevOps Server
+	// uses the address of the server, e.g. https://server/tfs.
+	DefaultAzureDevOpsURL = "https://dev.azure.com"
+
+	azureAPIVersion = "6.0"
+	azurePageSize   = 100
+)
+
+// AzureDevOps discovers the Git repositories in every project of an Azure
+// DevOps organization or Azure DevOps Server collection
+type AzureDevOps struct {
+	cfg     config.SourceConfig
+	baseURL string
+	client  *apiClient
+}
+
+// azureProjectList is a page of the project list
+type azureProjectList struct {
+	Value []struct {
+		Name       string `json:"name"`
+		Visibility string `json:"visibility"`
+	} `json:"value"`
+}
+
+// azureRepositoryList is the repository list of a project
+type azureRepositoryList struct {
+	Value []struct {
+		Name       string `json:"name"`
+		RemoteURL  string `json:"remoteUrl"`
+		SSHURL     string `json:"sshUrl"`
+		IsDisabled bool   `json:"isDisabled"`
+		IsFork     bool   `json:"isFork"`
+	} `json:"value"`
+}
+
+// NewAzureDevOps creates an Azure DevOps source authenticating with a
+// personal access token
+func NewAzureDevOps(src config.SourceConfig, token string) (*AzureDevOps, error) {
+	if src.Owner == "" {
+		return nil, fmt.Errorf("azure-devops source needs an organization or collection as owner")
+	}
+
+	baseURL := src.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultAzureDevOpsURL
+	}
+
+	baseURL = strings.TrimSuffix(baseURL, "/") + "/" + url.PathEscape(src.Owner)
+	client, err := newAPIClient(baseURL, func(req *http.Request) {
+		if token != "" {
+			// personal access tokens go in the password with any username
+			req.SetBasicAuth("", token)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &AzureDevOps{
+		cfg:     src,
+		baseURL: baseURL,
+		client:  client,
+	}, nil
+}
+
+// Repositories implements Source
+func (a *AzureDevOps) Repositories() ([]csv.RepoSpec, error) {
+	var repos []remoteRepo
+
+	continuation := ""
+	for {
+		next := fmt.Sprintf("%s/_apis/projects?api-version=%s&$top=%d", a.baseURL, azureAPIVersion, azurePageSize)
+		if continuation != "" {
+			next += "&continuationToken=" + url.QueryEscape(continuation)
+		}
+
+		var projects azureProjectList
+		header, err := a.client.getJSON(next, &projects)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list azure devops projects of %s: %w", a.cfg.Owner, err)
+		}
+
+		for _, project := range projects.Value {
+			var list azureRepositoryList
+			reposURL := fmt.Sprintf("%s/%s/_apis/git/repositories?api-version=%s",
+				a.baseURL, url.PathEscape(project.Name), azureAPIVersion)
+			if _, err := a.client.getJSON(reposURL, &list); err != nil {
+				return nil, fmt.Errorf("failed to list azure devops repositories of %s: %w", project.Name, err)
+			}
+
+			for _, r := range list.Value {
+				repo := remoteRepo{
+					Path:       project.Name + "/" + r.Name,
+					HTTPSURL:   r.RemoteURL,
+					SSHURL:     r.SSHURL,
+					Disabled:   r.IsDisabled,
+					Fork:       r.IsFork,
+					Visibility: project.Visibility,
+				}
+				if a.cfg.MirrorHierarchy {
+					repo.Dest = filepath.Join(project.Name, r.Name)
+				}
+				repos = append(repos, repo)
+			}
+		}
+
+		continuation = header.Get("X-Ms-Continuationtoken")
+		if continuation == "" || len(projects.Value) == 0 {
+			break
+		}
+	}
+
+	return toSpecs(repos, a.cfg)
+}
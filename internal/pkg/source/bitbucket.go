@@ -0,0 +1,227 @@
+package source
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/dmaharana/clone-git-repo/internal/pkg/config"
+	"github.com/dmaharana/clone-git-repo/internal/pkg/csv"
+)
+
+const (
+	// DefaultBitbucketCloudURL is the API of bitbucket.org
+	DefaultBitbucketCloudURL = "https://api.bitbucket.org"
+
+	bitbucketPageSize = 100
+)
+
+// bitbucketLinks holds the clone links shared by both Bitbucket flavours
+type bitbucketLinks struct {
+	Clone []struct {
+		Href string `json:"href"`
+		Name string `json:"name"`
+	} `json:"clone"`
+}
+
+// cloneURLs returns the HTTPS and SSH clone links
+func (l bitbucketLinks) cloneURLs() (string, string) {
+	var httpsURL, sshURL string
+	for _, link := range l.Clone {
+		switch strings.ToLower(link.Name) {
+		case "http", "https":
+			httpsURL = link.Href
+		case "ssh":
+			sshURL = link.Href
+		}
+	}
+	return httpsURL, sshURL
+}
+
+// bitbucketAuth uses basic authentication when a username is known, which
+// works with app passwords and personal access tokens alike, and falls
+// back to a bearer token for project and repository access tokens
+func bitbucketAuth(username, token string) func(req *http.Request) {
+	return func(req *http.Request) {
+		switch {
+		case token == "":
+		case username != "":
+			req.SetBasicAuth(username, token)
+		default:
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+}
+
+// BitbucketServer discovers the repositories of a Bitbucket Server or Data
+// Center project
+type BitbucketServer struct {
+	cfg     config.SourceConfig
+	baseURL string
+	client  *apiClient
+}
+
+// bitbucketServerPage is a page of the Bitbucket Server repository list
+type bitbucketServerPage struct {
+	IsLastPage    bool `json:"isLastPage"`
+	NextPageStart int  `json:"nextPageStart"`
+	Values        []struct {
+		Slug     string `json:"slug"`
+		Public   bool   `json:"public"`
+		Archived bool   `json:"archived"`
+		Project  struct {
+			Key string `json:"key"`
+		} `json:"project"`
+		Origin *struct {
+			Slug string `json:"slug"`
+		} `json:"origin"`
+		Links bitbucketLinks `json:"links"`
+	} `json:"values"`
+}
+
+// NewBitbucketServer creates a Bitbucket Server source for the project key in
+// the owner setting
+func NewBitbucketServer(src config.SourceConfig, username, token string) (*BitbucketServer, error) {
+	if src.Owner == "" {
+		return nil, fmt.Errorf("bitbucket-server source needs a project key as owner")
+	}
+	if src.BaseURL == "" {
+		return nil, fmt.Errorf("bitbucket-server source needs a base_url")
+	}
+
+	baseURL := strings.TrimSuffix(src.BaseURL, "/")
+	client, err := newAPIClient(baseURL, bitbucketAuth(username, token))
+	if err != nil {
+		return nil, err
+	}
+
+	return &BitbucketServer{
+		cfg:     src,
+		baseURL: baseURL,
+		client:  client,
+	}, nil
+}
+
+// Repositories implements Source
+func (b *BitbucketServer) Repositories() ([]csv.RepoSpec, error) {
+	var repos []remoteRepo
+
+	start := 0
+	for {
+		next := fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos?limit=%d&start=%d",
+			b.baseURL, url.PathEscape(b.cfg.Owner), bitbucketPageSize, start)
+
+		var page bitbucketServerPage
+		if _, err := b.client.getJSON(next, &page); err != nil {
+			return nil, fmt.Errorf("failed to list bitbucket repositories of %s: %w", b.cfg.Owner, err)
+		}
+
+		for _, r := range page.Values {
+			httpsURL, sshURL := r.Links.cloneURLs()
+			visibility := "private"
+			if r.Public {
+				visibility = "public"
+			}
+
+			repo := remoteRepo{
+				Path:       r.Project.Key + "/" + r.Slug,
+				HTTPSURL:   httpsURL,
+				SSHURL:     sshURL,
+				Archived:   r.Archived,
+				Fork:       r.Origin != nil,
+				Visibility: visibility,
+			}
+			if b.cfg.MirrorHierarchy {
+				repo.Dest = filepath.Join(r.Project.Key, r.Slug)
+			}
+			repos = append(repos, repo)
+		}
+
+		if page.IsLastPage || page.NextPageStart <= start {
+			break
+		}
+		start = page.NextPageStart
+	}
+
+	return toSpecs(repos, b.cfg)
+}
+
+// BitbucketCloud discovers the repositories of a Bitbucket Cloud workspace
+type BitbucketCloud struct {
+	cfg     config.SourceConfig
+	baseURL string
+	client  *apiClient
+}
+
+// bitbucketCloudPage is a page of the Bitbucket Cloud repository list
+type bitbucketCloudPage struct {
+	Next   string `json:"next"`
+	Values []struct {
+		FullName  string `json:"full_name"`
+		IsPrivate bool   `json:"is_private"`
+		Parent    *struct {
+			FullName string `json:"full_name"`
+		} `json:"parent"`
+		Links bitbucketLinks `json:"links"`
+	} `json:"values"`
+}
+
+// NewBitbucketCloud creates a Bitbucket Cloud source for the workspace in the
+// owner setting
+func NewBitbucketCloud(src config.SourceConfig, username, token string) (*BitbucketCloud, error) {
+	if src.Owner == "" {
+		return nil, fmt.Errorf("bitbucket-cloud source needs a workspace as owner")
+	}
+
+	baseURL := src.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultBitbucketCloudURL
+	}
+
+	baseURL = strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/2.0")
+	client, err := newAPIClient(baseURL, bitbucketAuth(username, token))
+	if err != nil {
+		return nil, err
+	}
+
+	return &BitbucketCloud{
+		cfg:     src,
+		baseURL: baseURL,
+		client:  client,
+	}, nil
+}
+
+// Repositories implements Source
+func (b *BitbucketCloud) Repositories() ([]csv.RepoSpec, error) {
+	var repos []remoteRepo
+
+	next := fmt.Sprintf("%s/2.0/repositories/%s?pagelen=%d", b.baseURL, url.PathEscape(b.cfg.Owner), bitbucketPageSize)
+	for next != "" {
+		var page bitbucketCloudPage
+		if _, err := b.client.getJSON(next, &page); err != nil {
+			return nil, fmt.Errorf("failed to list bitbucket repositories of %s: %w", b.cfg.Owner, err)
+		}
+
+		for _, r := range page.Values {
+			httpsURL, sshURL := r.Links.cloneURLs()
+			visibility := "public"
+			if r.IsPrivate {
+				visibility = "private"
+			}
+
+			repos = append(repos, remoteRepo{
+				Path:       r.FullName,
+				HTTPSURL:   httpsURL,
+				SSHURL:     sshURL,
+				Fork:       r.Parent != nil,
+				Visibility: visibility,
+			})
+		}
+
+		next = page.Next
+	}
+
+	return toSpecs(repos, b.cfg)
+}
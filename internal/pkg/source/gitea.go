@@ -0,0 +1,110 @@
+package source
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/dmaharana/clone-git-repo/internal/pkg/config"
+	"github.com/dmaharana/clone-git-repo/internal/pkg/csv"
+)
+
+const (
+	// giteaPageSize is the default maximum page size of Gitea and Forgejo
+	giteaPageSize = 50
+)
+
+// Gitea discovers the repositories of a Gitea or Forgejo organization or user
+type Gitea struct {
+	cfg     config.SourceConfig
+	baseURL string
+	client  *apiClient
+}
+
+// giteaRepo is the subset of the Gitea repository resource we need
+type giteaRepo struct {
+	FullName string   `json:"full_name"`
+	CloneURL string   `json:"clone_url"`
+	SSHURL   string   `json:"ssh_url"`
+	Archived bool     `json:"archived"`
+	Fork     bool     `json:"fork"`
+	Private  bool     `json:"private"`
+	Internal bool     `json:"internal"`
+	Topics   []string `json:"topics"`
+}
+
+// NewGitea creates a Gitea source. The base URL is the address of the
+// instance, the /api/v1 suffix is added automatically.
+func NewGitea(src config.SourceConfig, token string) (*Gitea, error) {
+	if src.Owner == "" {
+		return nil, fmt.Errorf("gitea source needs an owner")
+	}
+	if src.BaseURL == "" {
+		return nil, fmt.Errorf("gitea source needs a base_url")
+	}
+
+	baseURL := strings.TrimSuffix(strings.TrimSuffix(src.BaseURL, "/"), "/api/v1")
+	client, err := newAPIClient(baseURL, func(req *http.Request) {
+		if token != "" {
+			req.Header.Set("Authorization", "token "+token)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Gitea{
+		cfg:     src,
+		baseURL: baseURL,
+		client:  client,
+	}, nil
+}
+
+// Repositories implements Source
+func (g *Gitea) Repositories() ([]csv.RepoSpec, error) {
+	kind := "orgs"
+	if g.cfg.OwnerType == "user" {
+		kind = "users"
+	}
+
+	var repos []remoteRepo
+	for page := 1; ; page++ {
+		next := fmt.Sprintf("%s/api/v1/%s/%s/repos?limit=%d&page=%d",
+			g.baseURL, kind, url.PathEscape(g.cfg.Owner), giteaPageSize, page)
+
+		var list []giteaRepo
+		header, err := g.client.getJSON(next, &list)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list gitea repositories of %s: %w", g.cfg.Owner, err)
+		}
+
+		for _, r := range list {
+			visibility := "public"
+			switch {
+			case r.Private:
+				visibility = "private"
+			case r.Internal:
+				visibility = "internal"
+			}
+
+			repos = append(repos, remoteRepo{
+				Path:       r.FullName,
+				HTTPSURL:   r.CloneURL,
+				SSHURL:     r.SSHURL,
+				Archived:   r.Archived,
+				Fork:       r.Fork,
+				Visibility: visibility,
+				Topics:     r.Topics,
+			})
+		}
+
+		// the server may cap the page size below what was asked for, so
+		// only an empty page or a missing next link ends the listing
+		if len(list) == 0 || (header.Get("Link") != "" && nextLink(header) == "") {
+			break
+		}
+	}
+
+	return toSpecs(repos, g.cfg)
+}
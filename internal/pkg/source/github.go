@@ -0,0 +1,122 @@
+package source
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/dmaharana/clone-git-repo/internal/pkg/config"
+	"github.com/dmaharana/clone-git-repo/internal/pkg/csv"
+)
+
+const (
+	// DefaultGitHubURL is the API of github.com. GitHub Enterprise Server
+	// serves its API under https://<host>/api/v3.
+	DefaultGitHubURL = "https://api.github.com"
+
+	githubPageSize = 100
+)
+
+// GitHub discovers the repositories of a GitHub organization or user
+type GitHub struct {
+	cfg      config.SourceConfig
+	baseURL  string
+	username string
+	client   *apiClient
+}
+
+// githubRepo is the subset of the GitHub repository resource we need
+type githubRepo struct {
+	FullName   string   `json:"full_name"`
+	CloneURL   string   `json:"clone_url"`
+	SSHURL     string   `json:"ssh_url"`
+	Archived   bool     `json:"archived"`
+	Fork       bool     `json:"fork"`
+	Disabled   bool     `json:"disabled"`
+	Private    bool     `json:"private"`
+	Visibility string   `json:"visibility"`
+	Topics     []string `json:"topics"`
+}
+
+// NewGitHub creates a GitHub source. The token, if any, is sent as a bearer
+// token so private repositories are listed too.
+func NewGitHub(src config.SourceConfig, username, token string) (*GitHub, error) {
+	if src.Owner == "" {
+		return nil, fmt.Errorf("github source needs an owner")
+	}
+
+	baseURL := src.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultGitHubURL
+	}
+
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	client, err := newAPIClient(baseURL, bearerAuth(token))
+	if err != nil {
+		return nil, err
+	}
+
+	return &GitHub{
+		cfg:      src,
+		baseURL:  baseURL,
+		username: username,
+		client:   client,
+	}, nil
+}
+
+// Repositories implements Source
+func (g *GitHub) Repositories() ([]csv.RepoSpec, error) {
+	var repos []remoteRepo
+
+	next := g.firstPage()
+	for next != "" {
+		var page []githubRepo
+		header, err := g.client.getJSON(next, &page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list github repositories of %s: %w", g.cfg.Owner, err)
+		}
+
+		for _, r := range page {
+			visibility := r.Visibility
+			if visibility == "" {
+				// older GitHub Enterprise versions only report private
+				visibility = "public"
+				if r.Private {
+					visibility = "private"
+				}
+			}
+
+			repos = append(repos, remoteRepo{
+				Path:       r.FullName,
+				HTTPSURL:   r.CloneURL,
+				SSHURL:     r.SSHURL,
+				Archived:   r.Archived,
+				Fork:       r.Fork,
+				Disabled:   r.Disabled,
+				Visibility: visibility,
+				Topics:     r.Topics,
+			})
+		}
+
+		next = nextLink(header)
+	}
+
+	return toSpecs(repos, g.cfg)
+}
+
+// firstPage returns the URL listing the owner's repositories. The
+// authenticated user's own repositories are listed through /user/repos,
+// which is the only endpoint that includes their private repositories.
+func (g *GitHub) firstPage() string {
+	owner := url.PathEscape(g.cfg.Owner)
+	query := fmt.Sprintf("per_page=%d", githubPageSize)
+
+	switch {
+	case g.cfg.OwnerType != "user":
+		return fmt.Sprintf("%s/orgs/%s/repos?type=all&%s", g.baseURL, owner, query)
+	case strings.EqualFold(g.cfg.Owner, g.username):
+		return fmt.Sprintf("%s/user/repos?affiliation=owner&%s", g.baseURL, query)
+	default:
+		return fmt.Sprintf("%s/users/%s/repos?type=owner&%s", g.baseURL, owner, query)
+	}
+}
@@ -0,0 +1,132 @@
+package source
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/dmaharana/clone-git-repo/internal/pkg/config"
+	"github.com/dmaharana/clone-git-repo/internal/pkg/csv"
+)
+
+const (
+	// DefaultGitLabURL is gitlab.com. Self-hosted instances use their own
+	// address, the /api/v4 suffix is added automatically.
+	DefaultGitLabURL = "https://gitlab.com"
+
+	gitlabPageSize = 100
+)
+
+// GitLab discovers the projects of a GitLab group and all its subgroups
+type GitLab struct {
+	cfg     config.SourceConfig
+	baseURL string
+	client  *apiClient
+}
+
+// gitlabProject is the subset of the GitLab project resource we need
+type gitlabProject struct {
+	PathWithNamespace string   `json:"path_with_namespace"`
+	HTTPURL           string   `json:"http_url_to_repo"`
+	SSHURL            string   `json:"ssh_url_to_repo"`
+	Archived          bool     `json:"archived"`
+	Visibility        string   `json:"visibility"`
+	Topics            []string `json:"topics"`
+	TagList           []string `json:"tag_list"` // topics before GitLab 14.0
+	ForkedFrom        *struct {
+		ID int `json:"id"`
+	} `json:"forked_from_project"`
+}
+
+// NewGitLab creates a GitLab source authenticating with a personal access token
+func NewGitLab(src config.SourceConfig, token string) (*GitLab, error) {
+	if src.Owner == "" {
+		return nil, fmt.Errorf("gitlab source needs a group as owner")
+	}
+
+	baseURL := src.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultGitLabURL
+	}
+	baseURL = strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/api/v4")
+
+	client, err := newAPIClient(baseURL, func(req *http.Request) {
+		if token != "" {
+			req.Header.Set("PRIVATE-TOKEN", token)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &GitLab{
+		cfg:     src,
+		baseURL: baseURL,
+		client:  client,
+	}, nil
+}
+
+// Repositories implements Source
+func (g *GitLab) Repositories() ([]csv.RepoSpec, error) {
+	var repos []remoteRepo
+
+	next := fmt.Sprintf("%s/api/v4/groups/%s/projects?include_subgroups=true&order_by=path&sort=asc&per_page=%d",
+		g.baseURL, url.PathEscape(strings.Trim(g.cfg.Owner, "/")), gitlabPageSize)
+	for next != "" {
+		var page []gitlabProject
+		header, err := g.client.getJSON(next, &page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list gitlab projects of %s: %w", g.cfg.Owner, err)
+		}
+
+		for _, p := range page {
+			topics := p.Topics
+			if len(topics) == 0 {
+				topics = p.TagList
+			}
+
+			repo := remoteRepo{
+				Path:       p.PathWithNamespace,
+				HTTPSURL:   p.HTTPURL,
+				SSHURL:     p.SSHURL,
+				Archived:   p.Archived,
+				Fork:       p.ForkedFrom != nil,
+				Visibility: p.Visibility,
+				Topics:     topics,
+			}
+			// a project such as grp/a next to grp/a/b nests one clone in
+			// the other, which the directory planning reports as a collision
+			if g.cfg.MirrorHierarchy {
+				repo.Dest = filepath.FromSlash(p.PathWithNamespace)
+			}
+			repos = append(repos, repo)
+		}
+
+		next = g.nextPage(next, header)
+	}
+
+	return toSpecs(repos, g.cfg)
+}
+
+// nextPage returns the URL of the next page, from the Link header or, when
+// a proxy strips it, from the X-Next-Page header
+func (g *GitLab) nextPage(current string, header http.Header) string {
+	if link := nextLink(header); link != "" {
+		return link
+	}
+
+	page := header.Get("X-Next-Page")
+	if page == "" {
+		return ""
+	}
+	u, err := url.Parse(current)
+	if err != nil {
+		return ""
+	}
+	q := u.Query()
+	q.Set("page", page)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
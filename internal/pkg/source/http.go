@@ -0,0 +1,113 @@
+package source
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// Link header entry pointing at the next page: <url>; rel="next"
+	nextLinkRegex = regexp.MustCompile(`<([^>]+)>\s*;\s*rel="next"`)
+)
+
+// apiClient performs authenticated JSON requests against a provider API
+type apiClient struct {
+	http *http.Client
+	// base is the API base URL, the only origin credentials are sent to
+	base *url.URL
+	// authorize adds the provider specific credentials to a request
+	authorize func(req *http.Request)
+}
+
+// newAPIClient creates an API client for the API at baseURL with a sensible
+// timeout
+func newAPIClient(baseURL string, authorize func(req *http.Request)) (*apiClient, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("invalid base_url %q", baseURL)
+	}
+
+	c := &apiClient{base: base, authorize: authorize}
+	c.http = &http.Client{
+		Timeout: 30 * time.Second,
+		// the client copies the credentials onto redirects, so they must
+		// stay on the base URL as the pages do
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if !c.allowed(req.URL) {
+				return fmt.Errorf("refusing to follow redirect to %s outside of %s://%s",
+					req.URL.Redacted(), base.Scheme, base.Host)
+			}
+			if len(via) >= 10 {
+				return fmt.Errorf("stopped after %d redirects", len(via))
+			}
+			return nil
+		},
+	}
+	return c, nil
+}
+
+// allowed reports whether u has the scheme and host of the base URL
+func (c *apiClient) allowed(u *url.URL) bool {
+	return strings.EqualFold(u.Scheme, c.base.Scheme) && strings.EqualFold(u.Host, c.base.Host)
+}
+
+// getJSON fetches url and decodes the JSON response into out, returning the
+// response headers for pagination. Pages are followed from links sent by the
+// server, so one outside the base URL's scheme and host is refused rather
+// than handed the credentials, and so is a redirect there.
+func (c *apiClient) getJSON(rawURL string, out interface{}) (http.Header, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !c.allowed(req.URL) {
+		return nil, fmt.Errorf("refusing to request %s outside of %s://%s", req.URL.Redacted(), c.base.Scheme, c.base.Host)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.authorize != nil {
+		c.authorize(req)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("request to %s failed with status %s: %s", req.URL.Redacted(), resp.Status, body)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return nil, fmt.Errorf("failed to decode response from %s: %w", req.URL.Redacted(), err)
+	}
+
+	return resp.Header, nil
+}
+
+// nextLink returns the URL of the next page from a Link header, or an empty
+// string on the last page
+func nextLink(header http.Header) string {
+	for _, link := range header.Values("Link") {
+		if m := nextLinkRegex.FindStringSubmatch(link); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// bearerAuth authorizes requests with a bearer token, if one is set
+func bearerAuth(token string) func(req *http.Request) {
+	return func(req *http.Request) {
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+}
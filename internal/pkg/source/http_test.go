@@ -0,0 +1,69 @@
+package source
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dmaharana/clone-git-repo/internal/pkg/config"
+)
+
+func TestGetJSONRefusesOtherHosts(t *testing.T) {
+	tests := []struct {
+		name      string
+		newSource func(baseURL string) (Source, error)
+		// respond sends the API response pointing at the other server
+		respond func(w http.ResponseWriter, r *http.Request, other string)
+	}{
+		{
+			name: "next link",
+			newSource: func(baseURL string) (Source, error) {
+				return NewGitHub(config.SourceConfig{Type: "github", BaseURL: baseURL, Owner: "acme"}, "", "secret")
+			},
+			respond: func(w http.ResponseWriter, r *http.Request, other string) {
+				w.Header().Set("Link", `<`+other+`/orgs/acme/repos?page=2>; rel="next"`)
+				w.Write([]byte("[]"))
+			},
+		},
+		{
+			// the client drops Authorization on a redirect to another host
+			// by itself, but not GitLab's PRIVATE-TOKEN
+			name: "redirect",
+			newSource: func(baseURL string) (Source, error) {
+				return NewGitLab(config.SourceConfig{Type: "gitlab", BaseURL: baseURL, Owner: "acme"}, "secret")
+			},
+			respond: func(w http.ResponseWriter, r *http.Request, other string) {
+				http.Redirect(w, r, other+r.URL.Path, http.StatusFound)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var leaked bool
+			other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				leaked = r.Header.Get("Authorization") != "" || r.Header.Get("Private-Token") != ""
+				w.Write([]byte("[]"))
+			}))
+			defer other.Close()
+
+			api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				tt.respond(w, r, other.URL)
+			}))
+			defer api.Close()
+
+			s, err := tt.newSource(api.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = s.Repositories()
+			if err == nil || !strings.Contains(err.Error(), "refusing") {
+				t.Fatalf("Repositories() = %v, want the request refused", err)
+			}
+			if leaked {
+				t.Fatal("credentials were sent to another host")
+			}
+		})
+	}
+}
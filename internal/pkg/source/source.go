@@ -0,0 +1,203 @@
+package source
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dmaharana/clone-git-repo/internal/pkg/config"
+	"github.com/dmaharana/clone-git-repo/internal/pkg/csv"
+)
+
+// Source lists the repositories to clone
+type Source interface {
+	Repositories() ([]csv.RepoSpec, error)
+}
+
+// New returns the source selected in the configuration
+func New(cfg *config.Config) (Source, error) {
+	src := cfg.Source
+	if src.Type == "" || src.Type == config.DefaultSource {
+		return &CSVSource{Path: cfg.RepoCSV}, nil
+	}
+
+	// the provider API is accessed with the profile matching its URL
+	username, token, err := cfg.Credentials(apiURL(src), src.Profile)
+	if err != nil {
+		return nil, err
+	}
+
+	switch src.Type {
+	case "github":
+		return NewGitHub(src, username, token)
+	case "gitlab":
+		return NewGitLab(src, token)
+	case "bitbucket-server":
+		return NewBitbucketServer(src, username, token)
+	case "bitbucket-cloud":
+		return NewBitbucketCloud(src, username, token)
+	case "gitea", "forgejo":
+		return NewGitea(src, token)
+	case "azure-devops":
+		return NewAzureDevOps(src, token)
+	default:
+		return nil, fmt.Errorf("unknown repository source %q", src.Type)
+	}
+}
+
+// apiURL returns the API base URL of the provider
+func apiURL(src config.SourceConfig) string {
+	if src.BaseURL != "" {
+		return src.BaseURL
+	}
+
+	switch src.Type {
+	case "github":
+		return DefaultGitHubURL
+	case "gitlab":
+		return DefaultGitLabURL
+	case "bitbucket-cloud":
+		return DefaultBitbucketCloudURL
+	case "azure-devops":
+		return DefaultAzureDevOpsURL
+	}
+	return ""
+}
+
+// CSVSource reads repositories from a CSV file
+type CSVSource struct {
+	Path string
+}
+
+// Repositories implements Source
+func (s *CSVSource) Repositories() ([]csv.RepoSpec, error) {
+	return csv.ReadRepositories(s.Path)
+}
+
+// remoteRepo is the provider independent description of a discovered repository
+type remoteRepo struct {
+	Path       string // full path on the provider, e.g. org/repo
+	HTTPSURL   string
+	SSHURL     string
+	Archived   bool
+	Fork       bool
+	Disabled   bool
+	Visibility string
+	Topics     []string
+	Dest       string // optional directory, for providers that mirror their hierarchy
+}
+
+// toSpecs filters discovered repositories and turns them into repository specs
+func toSpecs(repos []remoteRepo, src config.SourceConfig) ([]csv.RepoSpec, error) {
+	var specs []csv.RepoSpec
+	for _, repo := range repos {
+		ok, err := keep(repo, src)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		url := repo.HTTPSURL
+		if src.Protocol == "ssh" && repo.SSHURL != "" {
+			url = repo.SSHURL
+		}
+		if url == "" {
+			continue
+		}
+
+		specs = append(specs, csv.RepoSpec{
+			URL:  url,
+			Dest: repo.Dest,
+			Tags: repo.Topics,
+		})
+	}
+
+	return specs, nil
+}
+
+// keep reports whether a discovered repository passes the configured filters
+func keep(repo remoteRepo, src config.SourceConfig) (bool, error) {
+	if repo.Disabled {
+		return false, nil
+	}
+	if repo.Archived && !src.IncludeArchived {
+		return false, nil
+	}
+	if repo.Fork && !src.IncludeForks {
+		return false, nil
+	}
+	if src.Visibility != "" && src.Visibility != "all" && !strings.EqualFold(repo.Visibility, src.Visibility) {
+		return false, nil
+	}
+
+	if len(src.Topics) > 0 && !hasAnyTopic(repo.Topics, src.Topics) {
+		return false, nil
+	}
+
+	if len(src.Include) > 0 {
+		matched, err := matchAny(src.Include, repo.Path)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	if len(src.Exclude) > 0 {
+		matched, err := matchAny(src.Exclude, repo.Path)
+		if err != nil || matched {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// hasAnyTopic reports whether topics contains one of wanted
+func hasAnyTopic(topics []string, wanted []string) bool {
+	for _, topic := range topics {
+		for _, w := range wanted {
+			if strings.EqualFold(topic, strings.TrimSpace(w)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchAny reports whether name matches one of the glob patterns. A '*'
+// matches within a path segment and '**' matches across segments.
+func matchAny(patterns []string, name string) (bool, error) {
+	for _, pattern := range patterns {
+		re, err := globToRegexp(strings.TrimSpace(pattern))
+		if err != nil {
+			return false, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		if re.MatchString(name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// globToRegexp converts a path glob into an anchored regular expression
+func globToRegexp(glob string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				sb.WriteString(".*")
+				i++
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
@@ -0,0 +1,377 @@
+package source
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dmaharana/clone-git-repo/internal/pkg/config"
+)
+
+// testRepo is a repository served by the fake provider APIs
+type testRepo struct {
+	Name     string
+	Archived bool
+	Fork     bool
+	Private  bool
+}
+
+// testRepos are served over two pages, so every listing must paginate to
+// see them all
+var testRepos = []testRepo{
+	{Name: "active"},
+	{Name: "old", Archived: true},
+	{Name: "copy", Fork: true},
+	{Name: "secret", Private: true},
+}
+
+// testPages splits testRepos into the two pages served
+func testPages() [][]testRepo {
+	return [][]testRepo{testRepos[:2], testRepos[2:]}
+}
+
+// testCloneURL returns the clone URL served for a repository
+func testCloneURL(name string) string {
+	return "https://git.example.com/acme/" + name + ".git"
+}
+
+// visibility returns the visibility of a repository as most APIs name it
+func (r testRepo) visibility() string {
+	if r.Private {
+		return "private"
+	}
+	return "public"
+}
+
+// writeJSON writes v as the JSON response
+func writeJSON(t *testing.T, w http.ResponseWriter, v interface{}) {
+	t.Helper()
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		t.Error(err)
+	}
+}
+
+// pageParam returns the 1-based page number in the named query parameter
+func pageParam(r *http.Request, name string) int {
+	page, err := strconv.Atoi(r.URL.Query().Get(name))
+	if err != nil || page < 1 {
+		return 1
+	}
+	return page
+}
+
+// filterCase is a set of source filters and the repositories they keep
+type filterCase struct {
+	name   string
+	filter func(src *config.SourceConfig)
+	want   []string
+}
+
+// filterCases returns the cases run against every provider. Providers that
+// do not report archived repositories never list the archived one.
+func filterCases(archived bool) []filterCase {
+	cases := []filterCase{
+		{
+			name:   "defaults skip archived and forks",
+			filter: func(src *config.SourceConfig) {},
+			want:   []string{"active", "secret"},
+		},
+		{
+			name: "include archived and forks",
+			filter: func(src *config.SourceConfig) {
+				src.IncludeArchived = true
+				src.IncludeForks = true
+			},
+			want: []string{"active", "old", "copy", "secret"},
+		},
+		{
+			name:   "public only",
+			filter: func(src *config.SourceConfig) { src.Visibility = "public" },
+			want:   []string{"active"},
+		},
+		{
+			name: "private only with forks",
+			filter: func(src *config.SourceConfig) {
+				src.Visibility = "private"
+				src.IncludeForks = true
+			},
+			want: []string{"secret"},
+		},
+	}
+
+	if !archived {
+		for i := range cases {
+			var want []string
+			for _, name := range cases[i].want {
+				if name != "old" {
+					want = append(want, name)
+				}
+			}
+			cases[i].want = want
+		}
+	}
+	return cases
+}
+
+// runFilterCases lists the repositories of the fake provider at baseURL
+// with every filter case
+func runFilterCases(t *testing.T, sourceType, baseURL string, archived bool,
+	newSource func(src config.SourceConfig) (Source, error)) {
+	t.Helper()
+
+	for _, tc := range filterCases(archived) {
+		t.Run(tc.name, func(t *testing.T) {
+			src := config.SourceConfig{Type: sourceType, BaseURL: baseURL, Owner: "acme"}
+			tc.filter(&src)
+
+			s, err := newSource(src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			specs, err := s.Repositories()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, spec := range specs {
+				got = append(got, strings.TrimSuffix(path.Base(spec.URL), ".git"))
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Repositories() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGitHub(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/orgs/acme/repos" {
+			http.NotFound(w, r)
+			return
+		}
+
+		page := pageParam(r, "page")
+		if page == 1 {
+			w.Header().Set("Link", `<`+srv.URL+`/orgs/acme/repos?page=2>; rel="next", <`+srv.URL+`/orgs/acme/repos?page=2>; rel="last"`)
+		}
+		var list []githubRepo
+		for _, repo := range testPages()[page-1] {
+			list = append(list, githubRepo{
+				FullName:   "acme/" + repo.Name,
+				CloneURL:   testCloneURL(repo.Name),
+				Archived:   repo.Archived,
+				Fork:       repo.Fork,
+				Private:    repo.Private,
+				Visibility: repo.visibility(),
+			})
+		}
+		writeJSON(t, w, list)
+	}))
+	defer srv.Close()
+
+	runFilterCases(t, "github", srv.URL, true, func(src config.SourceConfig) (Source, error) {
+		return NewGitHub(src, "", "token")
+	})
+}
+
+func TestGitLab(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/api/v4/groups/acme/projects" {
+			http.NotFound(w, r)
+			return
+		}
+
+		// the next page is only given in X-Next-Page, as behind proxies
+		// that strip the Link header
+		page := pageParam(r, "page")
+		if page == 1 {
+			w.Header().Set("X-Next-Page", "2")
+		}
+		var list []gitlabProject
+		for _, repo := range testPages()[page-1] {
+			p := gitlabProject{
+				PathWithNamespace: "acme/" + repo.Name,
+				HTTPURL:           testCloneURL(repo.Name),
+				Archived:          repo.Archived,
+				Visibility:        repo.visibility(),
+			}
+			if repo.Fork {
+				p.ForkedFrom = &struct {
+					ID int `json:"id"`
+				}{ID: 1}
+			}
+			list = append(list, p)
+		}
+		writeJSON(t, w, list)
+	}))
+	defer srv.Close()
+
+	runFilterCases(t, "gitlab", srv.URL, true, func(src config.SourceConfig) (Source, error) {
+		return NewGitLab(src, "token")
+	})
+}
+
+func TestBitbucketServer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/1.0/projects/acme/repos" {
+			http.NotFound(w, r)
+			return
+		}
+
+		start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+		pages := testPages()
+		page := map[string]interface{}{"isLastPage": start > 0}
+		var values []interface{}
+		repos := pages[0]
+		if start > 0 {
+			repos = pages[1]
+		} else {
+			page["nextPageStart"] = len(pages[0])
+		}
+		for _, repo := range repos {
+			value := map[string]interface{}{
+				"slug":     repo.Name,
+				"public":   !repo.Private,
+				"archived": repo.Archived,
+				"project":  map[string]string{"key": "acme"},
+				"links": map[string]interface{}{
+					"clone": []map[string]string{{"href": testCloneURL(repo.Name), "name": "http"}},
+				},
+			}
+			if repo.Fork {
+				value["origin"] = map[string]string{"slug": "upstream"}
+			}
+			values = append(values, value)
+		}
+		page["values"] = values
+		writeJSON(t, w, page)
+	}))
+	defer srv.Close()
+
+	runFilterCases(t, "bitbucket-server", srv.URL, true, func(src config.SourceConfig) (Source, error) {
+		return NewBitbucketServer(src, "user", "token")
+	})
+}
+
+func TestBitbucketCloud(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/2.0/repositories/acme" {
+			http.NotFound(w, r)
+			return
+		}
+
+		page := pageParam(r, "page")
+		body := map[string]interface{}{}
+		if page == 1 {
+			body["next"] = srv.URL + "/2.0/repositories/acme?page=2"
+		}
+		var values []interface{}
+		for _, repo := range testPages()[page-1] {
+			// Bitbucket Cloud has no archived repositories
+			if repo.Archived {
+				continue
+			}
+			value := map[string]interface{}{
+				"full_name":  "acme/" + repo.Name,
+				"is_private": repo.Private,
+				"links": map[string]interface{}{
+					"clone": []map[string]string{{"href": testCloneURL(repo.Name), "name": "https"}},
+				},
+			}
+			if repo.Fork {
+				value["parent"] = map[string]string{"full_name": "other/" + repo.Name}
+			}
+			values = append(values, value)
+		}
+		body["values"] = values
+		writeJSON(t, w, body)
+	}))
+	defer srv.Close()
+
+	runFilterCases(t, "bitbucket-cloud", srv.URL, false, func(src config.SourceConfig) (Source, error) {
+		return NewBitbucketCloud(src, "user", "token")
+	})
+}
+
+func TestGitea(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/orgs/acme/repos" {
+			http.NotFound(w, r)
+			return
+		}
+
+		// without a Link header the listing ends on the first empty page
+		list := []giteaRepo{}
+		if page := pageParam(r, "page"); page <= len(testPages()) {
+			for _, repo := range testPages()[page-1] {
+				list = append(list, giteaRepo{
+					FullName: "acme/" + repo.Name,
+					CloneURL: testCloneURL(repo.Name),
+					Archived: repo.Archived,
+					Fork:     repo.Fork,
+					Private:  repo.Private,
+				})
+			}
+		}
+		writeJSON(t, w, list)
+	}))
+	defer srv.Close()
+
+	runFilterCases(t, "gitea", srv.URL, true, func(src config.SourceConfig) (Source, error) {
+		return NewGitea(src, "token")
+	})
+}
+
+func TestAzureDevOps(t *testing.T) {
+	// repositories take the visibility of their project, so the private
+	// one gets a project of its own; Azure DevOps reports disabled rather
+	// than archived repositories, which are always skipped
+	projects := [][]testRepo{testRepos[:3], testRepos[3:]}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/acme/_apis/projects":
+			page := 1
+			if token := r.URL.Query().Get("continuationToken"); token != "" {
+				page, _ = strconv.Atoi(token)
+			}
+			if page < len(projects) {
+				w.Header().Set("X-Ms-Continuationtoken", strconv.Itoa(page+1))
+			}
+			visibility := projects[page-1][0].visibility()
+			writeJSON(t, w, map[string]interface{}{
+				"value": []map[string]string{{"name": "p" + strconv.Itoa(page), "visibility": visibility}},
+			})
+
+		case "/acme/p1/_apis/git/repositories", "/acme/p2/_apis/git/repositories":
+			page, _ := strconv.Atoi(strings.TrimPrefix(strings.Split(r.URL.Path, "/")[2], "p"))
+			var values []interface{}
+			for _, repo := range projects[page-1] {
+				values = append(values, map[string]interface{}{
+					"name":       repo.Name,
+					"remoteUrl":  testCloneURL(repo.Name),
+					"isDisabled": repo.Archived,
+					"isFork":     repo.Fork,
+				})
+			}
+			writeJSON(t, w, map[string]interface{}{"value": values})
+
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	runFilterCases(t, "azure-devops", srv.URL, false, func(src config.SourceConfig) (Source, error) {
+		return NewAzureDevOps(src, "token")
+	})
+}
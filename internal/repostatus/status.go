@@ -14,9 +14,20 @@ import (
 // RepoStatus represents the status information of a Git repository
 type RepoStatus struct {
 	RepoPath    string
+	Mode        string
+	Labels      []string
 	IsCloned    bool
 	BranchCount int
 	TagCount    int
+	Attempts    int
+	ErrorClass  string
+	Error       string
+
+	// Populated when an existing clone was synced instead of re-cloned
+	IsSynced        bool
+	NewCommits      int
+	NewBranches     []string
+	DeletedBranches []string
 }
 
 // GetRepoStatus retrieves the status information for a given repository path
@@ -72,19 +83,20 @@ func GetRepoStatus(repoPath string) (*RepoStatus, error) {
 // PrintStatusTable prints a table with repository status information
 func PrintStatusTable(statuses []*RepoStatus) {
 	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Repository", "Cloned", "Branches", "Tags"})
+	table.SetHeader([]string{"Repository", "Mode", "Cloned", "Synced", "Branches", "Tags", "New Commits", "New Branches", "Deleted Branches", "Error"})
 
 	for _, status := range statuses {
-		clonedStatus := "No"
-		if status.IsCloned {
-			clonedStatus = "Yes"
-		}
-
 		table.Append([]string{
 			logger.MaskSensitive(status.RepoPath),
-			clonedStatus,
+			status.Mode,
+			yesNo(status.IsCloned),
+			yesNo(status.IsSynced),
 			fmt.Sprintf("%d", status.BranchCount),
 			fmt.Sprintf("%d", status.TagCount),
+			fmt.Sprintf("%d", status.NewCommits),
+			fmt.Sprintf("%d", len(status.NewBranches)),
+			fmt.Sprintf("%d", len(status.DeletedBranches)),
+			status.ErrorClass,
 		})
 	}
 
@@ -100,7 +112,7 @@ func WriteStatusToCSV(statuses []*RepoStatus, filename string) error {
 	defer file.Close()
 
 	// Write header
-	header := []string{"Repository", "Cloned", "Branches", "Tags"}
+	header := []string{"Repository", "Mode", "Labels", "Cloned", "Synced", "Branches", "Tags", "New Commits", "New Branches", "Deleted Branches", "Attempts", "Error Class", "Error"}
 	writer := csv.NewWriter(file)
 	err = writer.Write(header)
 	if err != nil {
@@ -114,9 +126,18 @@ func WriteStatusToCSV(statuses []*RepoStatus, filename string) error {
 	for _, status := range statuses {
 		err := writer.Write([]string{
 			logger.MaskSensitive(status.RepoPath),
+			status.Mode,
+			logger.MaskSensitive(strings.Join(status.Labels, ";")),
 			fmt.Sprintf("%t", status.IsCloned),
+			fmt.Sprintf("%t", status.IsSynced),
 			fmt.Sprintf("%d", status.BranchCount),
 			fmt.Sprintf("%d", status.TagCount),
+			fmt.Sprintf("%d", status.NewCommits),
+			strings.Join(status.NewBranches, ";"),
+			strings.Join(status.DeletedBranches, ";"),
+			fmt.Sprintf("%d", status.Attempts),
+			status.ErrorClass,
+			logger.MaskSensitive(status.Error),
 		})
 		if err != nil {
 			return err
@@ -124,3 +145,11 @@ func WriteStatusToCSV(statuses []*RepoStatus, filename string) error {
 	}
 	return nil
 }
+
+// yesNo renders a boolean for the status table
+func yesNo(b bool) string {
+	if b {
+		return "Yes"
+	}
+	return "No"
+}
@@ -2,8 +2,10 @@ package metrics
 
 import (
 	"fmt"
+	"sort"
 	"time"
 
+	"github.com/dmaharana/clone-git-repo/internal/pkg/logger"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing/object"
 )
@@ -28,24 +30,37 @@ type RepositoryMetrics struct {
 	CodeChurn          map[string]int // Lines added/deleted per day
 	CommitFrequency    map[string]int // Commits per day
 	TimeBetweenCommits []time.Duration
+	Commits            []CommitMetrics // every commit analyzed, newest first
 }
 
 // Analyzer handles repository metric calculations
 type Analyzer struct {
 	repo *git.Repository
+	path string
+	opts Options
+	ids  *identityResolver
 }
 
-// NewAnalyzer creates a new repository analyzer
-func NewAnalyzer(repoPath string) (*Analyzer, error) {
+// NewAnalyzer creates a new repository analyzer walking the commits
+// selected by opts, or the history of HEAD if opts is nil
+func NewAnalyzer(repoPath string, opts *Options) (*Analyzer, error) {
 	repo, err := git.PlainOpen(repoPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open repository: %w", err)
 	}
-	return &Analyzer{repo: repo}, nil
+	a := &Analyzer{repo: repo, path: repoPath}
+	if opts != nil {
+		a.opts = *opts
+	}
+	if a.ids, err = newIdentityResolver(repo, repoPath, a.opts.Identity); err != nil {
+		return nil, err
+	}
+	return a, nil
 }
 
 // AnalyzeRepository performs a complete analysis of the repository
 func (a *Analyzer) AnalyzeRepository() (*RepositoryMetrics, error) {
+	start := time.Now()
 	metrics := &RepositoryMetrics{
 		UniqueAuthors:   make(map[string]bool),
 		CommitsByAuthor: make(map[string]int),
@@ -54,25 +69,15 @@ func (a *Analyzer) AnalyzeRepository() (*RepositoryMetrics, error) {
 		CommitFrequency: make(map[string]int),
 	}
 
-	ref, err := a.repo.Head()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get repository head: %w", err)
-	}
-
-	commits, err := a.repo.Log(&git.LogOptions{From: ref.Hash()})
-	if err != nil {
-		return nil, fmt.Errorf("failed to get commit log: %w", err)
-	}
-
 	var lastCommitTime *time.Time
 	totalLines := 0
 
-	err = commits.ForEach(func(c *object.Commit) error {
+	err := a.forEachCommit(func(c *object.Commit, author Identity) error {
 		// Track unique authors
-		metrics.UniqueAuthors[c.Author.Email] = true
+		metrics.UniqueAuthors[author.Email] = true
 
 		// Count commits by author
-		metrics.CommitsByAuthor[c.Author.Email]++
+		metrics.CommitsByAuthor[author.Email]++
 
 		// Count commits by date
 		dateStr := c.Author.When.Format("2006-01-02")
@@ -93,10 +98,19 @@ func (a *Analyzer) AnalyzeRepository() (*RepositoryMetrics, error) {
 			return fmt.Errorf("failed to get commit stats: %w", err)
 		}
 
+		commit := CommitMetrics{
+			Hash:         c.Hash.String(),
+			Author:       author.Email,
+			Date:         c.Author.When,
+			FilesChanged: len(stats),
+		}
 		for _, stat := range stats {
-			totalLines += stat.Addition + stat.Deletion
-			metrics.CodeChurn[dateStr] += stat.Addition + stat.Deletion
+			commit.LinesAdded += stat.Addition
+			commit.LinesDeleted += stat.Deletion
 		}
+		totalLines += commit.LinesAdded + commit.LinesDeleted
+		metrics.CodeChurn[dateStr] += commit.LinesAdded + commit.LinesDeleted
+		metrics.Commits = append(metrics.Commits, commit)
 
 		metrics.TotalCommits++
 		return nil
@@ -110,25 +124,32 @@ func (a *Analyzer) AnalyzeRepository() (*RepositoryMetrics, error) {
 		metrics.AverageCommitSize = float64(totalLines) / float64(metrics.TotalCommits)
 	}
 
+	log.Debug("Analyzed repository", logger.KeyDir, a.path, "commits", metrics.TotalCommits,
+		"authors", len(metrics.UniqueAuthors), logger.KeyDuration, time.Since(start))
+
 	return metrics, nil
 }
 
+// CommitsIn returns the commits made within the time range, oldest first
+func (m *RepositoryMetrics) CommitsIn(timeRange TimeRange) []CommitMetrics {
+	var commits []CommitMetrics
+	for _, c := range m.Commits {
+		if timeRange.Contains(c.Date) {
+			commits = append(commits, c)
+		}
+	}
+	sort.Slice(commits, func(i, j int) bool {
+		return commits[i].Date.Before(commits[j].Date)
+	})
+	return commits
+}
+
 // GetCommitFrequencyByAuthor returns the number of commits per author
 func (a *Analyzer) GetCommitFrequencyByAuthor() (map[string]int, error) {
 	commitsByAuthor := make(map[string]int)
 
-	ref, err := a.repo.Head()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get repository head: %w", err)
-	}
-
-	commits, err := a.repo.Log(&git.LogOptions{From: ref.Hash()})
-	if err != nil {
-		return nil, fmt.Errorf("failed to get commit log: %w", err)
-	}
-
-	err = commits.ForEach(func(c *object.Commit) error {
-		commitsByAuthor[c.Author.Email]++
+	err := a.forEachCommit(func(c *object.Commit, author Identity) error {
+		commitsByAuthor[author.Email]++
 		return nil
 	})
 
@@ -143,24 +164,14 @@ func (a *Analyzer) GetCommitFrequencyByAuthor() (map[string]int, error) {
 func (a *Analyzer) GetCodeChurnByAuthor() (map[string]int, error) {
 	churnByAuthor := make(map[string]int)
 
-	ref, err := a.repo.Head()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get repository head: %w", err)
-	}
-
-	commits, err := a.repo.Log(&git.LogOptions{From: ref.Hash()})
-	if err != nil {
-		return nil, fmt.Errorf("failed to get commit log: %w", err)
-	}
-
-	err = commits.ForEach(func(c *object.Commit) error {
+	err := a.forEachCommit(func(c *object.Commit, author Identity) error {
 		stats, err := c.Stats()
 		if err != nil {
 			return fmt.Errorf("failed to get commit stats: %w", err)
 		}
 
 		for _, stat := range stats {
-			churnByAuthor[c.Author.Email] += stat.Addition + stat.Deletion
+			churnByAuthor[author.Email] += stat.Addition + stat.Deletion
 		}
 		return nil
 	})
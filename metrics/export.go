@@ -9,37 +9,73 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/dmaharana/clone-git-repo/internal/pkg/logger"
 )
 
+// RollupRepository names the rows that cover every exported repository
+const RollupRepository = "(all)"
+
+// RepositoryReport holds the metrics of one repository, or of the rollup,
+// ready to be exported
+type RepositoryReport struct {
+	Repository string
+	Metrics    *RepositoryMetrics
+	Velocity   VelocityMetrics
+}
+
 // MetricsExporter handles the export of repository metrics to CSV
 type MetricsExporter struct {
 	outputPath string
 }
 
-// NewMetricsExporter creates a new metrics exporter
+// NewMetricsExporter creates a new metrics exporter. The summary is written
+// to outputPath, the author and timeline metrics next to it with _authors
+// and _timeline added to the name.
 func NewMetricsExporter(outputPath string) *MetricsExporter {
 	return &MetricsExporter{
 		outputPath: outputPath,
 	}
 }
 
-// ExportMetricsToCSV writes repository metrics to a CSV file
+// ExportMetricsToCSV writes the metrics of a single repository to the CSV
+// files
 func (e *MetricsExporter) ExportMetricsToCSV(repoPath string, metrics *RepositoryMetrics, velocity VelocityMetrics) error {
+	return e.Export([]RepositoryReport{{Repository: repoPath, Metrics: metrics, Velocity: velocity}}, nil)
+}
+
+// Export writes the summary, author and timeline CSV files with the rows of
+// every report keyed by repository, followed by the rows of the rollup if
+// it is not nil
+func (e *MetricsExporter) Export(reports []RepositoryReport, rollup *RepositoryReport) error {
 	// Create output directory if it doesn't exist
 	if err := os.MkdirAll(filepath.Dir(e.outputPath), 0755); err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	file, err := os.Create(e.outputPath)
-	if err != nil {
-		return fmt.Errorf("failed to create CSV file: %w", err)
+	if rollup != nil {
+		// the capped slice keeps the caller's backing array untouched
+		reports = append(reports[:len(reports):len(reports)], *rollup)
 	}
-	defer file.Close()
 
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
+	if err := e.exportSummary(reports); err != nil {
+		return fmt.Errorf("failed to export summary metrics: %w", err)
+	}
+
+	// Export detailed author metrics to separate files
+	if err := e.exportAuthorMetrics(reports); err != nil {
+		return fmt.Errorf("failed to export author metrics: %w", err)
+	}
+
+	if err := e.exportTimeBasedMetrics(reports); err != nil {
+		return fmt.Errorf("failed to export time-based metrics: %w", err)
+	}
+
+	return nil
+}
 
-	// Write headers
+// exportSummary writes one row of aggregated metrics per report
+func (e *MetricsExporter) exportSummary(reports []RepositoryReport) error {
 	headers := []string{
 		"Repository",
 		"Analysis Date",
@@ -53,157 +89,179 @@ func (e *MetricsExporter) ExportMetricsToCSV(repoPath string, metrics *Repositor
 		"Average Time Between Commits (hours)",
 	}
 
-	if err := writer.Write(headers); err != nil {
-		return fmt.Errorf("failed to write headers: %w", err)
+	analysisDate := time.Now().Format("2006-01-02 15:04:05")
+	rows := make([][]string, 0, len(reports))
+	for _, report := range reports {
+		metrics := report.Metrics
+
+		// Calculate additional metrics
+		productivityScore := CalculateProductivityScore(metrics)
+		avgTimeBetweenCommits := CalculateAverageTimeBetweenCommits(metrics.TimeBetweenCommits)
+
+		rows = append(rows, []string{
+			report.Repository,
+			analysisDate,
+			strconv.Itoa(metrics.TotalCommits),
+			strconv.Itoa(len(metrics.UniqueAuthors)),
+			fmt.Sprintf("%.2f", metrics.AverageCommitSize),
+			fmt.Sprintf("%.2f", report.Velocity.CommitsPerDay),
+			fmt.Sprintf("%.2f", report.Velocity.AverageLinesPerDay),
+			fmt.Sprintf("%.2f", report.Velocity.TrendSlope),
+			fmt.Sprintf("%.2f", productivityScore),
+			fmt.Sprintf("%.2f", avgTimeBetweenCommits.Hours()),
+		})
 	}
 
-	// Calculate additional metrics
-	productivityScore := CalculateProductivityScore(metrics)
-	avgTimeBetweenCommits := CalculateAverageTimeBetweenCommits(metrics.TimeBetweenCommits)
+	return writeCSV(e.outputPath, headers, rows)
+}
 
-	// Prepare row data
-	row := []string{
-		repoPath,
-		time.Now().Format("2006-01-02 15:04:05"),
-		strconv.Itoa(metrics.TotalCommits),
-		strconv.Itoa(len(metrics.UniqueAuthors)),
-		fmt.Sprintf("%.2f", metrics.AverageCommitSize),
-		fmt.Sprintf("%.2f", velocity.CommitsPerDay),
-		fmt.Sprintf("%.2f", velocity.AverageLinesPerDay),
-		fmt.Sprintf("%.2f", velocity.TrendSlope),
-		fmt.Sprintf("%.2f", productivityScore),
-		fmt.Sprintf("%.2f", avgTimeBetweenCommits.Hours()),
-	}
+// exportAuthorMetrics exports per-author statistics of every report to a
+// separate CSV file
+func (e *MetricsExporter) exportAuthorMetrics(reports []RepositoryReport) error {
+	headers := []string{"Repository", "Author", "Commit Count", "Contribution Percentage"}
 
-	if err := writer.Write(row); err != nil {
-		return fmt.Errorf("failed to write metrics row: %w", err)
-	}
+	var rows [][]string
+	for _, report := range reports {
+		metrics := report.Metrics
 
-	// Export detailed author metrics to separate files
-	if err := e.exportAuthorMetrics(repoPath, metrics); err != nil {
-		return fmt.Errorf("failed to export author metrics: %w", err)
-	}
-
-	if err := e.exportTimeBasedMetrics(repoPath, metrics); err != nil {
-		return fmt.Errorf("failed to export time-based metrics: %w", err)
+		// Get sorted list of authors
+		authors := make([]string, 0, len(metrics.CommitsByAuthor))
+		for author := range metrics.CommitsByAuthor {
+			authors = append(authors, author)
+		}
+		sort.Strings(authors)
+
+		for _, author := range authors {
+			commitCount := metrics.CommitsByAuthor[author]
+			percentage := float64(commitCount) / float64(metrics.TotalCommits) * 100
+
+			rows = append(rows, []string{
+				report.Repository,
+				author,
+				strconv.Itoa(commitCount),
+				fmt.Sprintf("%.2f", percentage),
+			})
+		}
 	}
 
-	return nil
+	return writeCSV(e.siblingPath("_authors"), headers, rows)
 }
 
-// exportAuthorMetrics exports per-author statistics to a separate CSV file
-func (e *MetricsExporter) exportAuthorMetrics(repoPath string, metrics *RepositoryMetrics) error {
-	authorFile := strings.TrimSuffix(e.outputPath, ".csv") + "_authors.csv"
-	file, err := os.Create(authorFile)
-	if err != nil {
-		return fmt.Errorf("failed to create author metrics file: %w", err)
-	}
-	defer file.Close()
+// exportTimeBasedMetrics exports the daily metrics of every report to a
+// separate CSV file
+func (e *MetricsExporter) exportTimeBasedMetrics(reports []RepositoryReport) error {
+	headers := []string{"Repository", "Date", "Commit Count", "Code Churn"}
 
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
+	var rows [][]string
+	for _, report := range reports {
+		metrics := report.Metrics
 
-	// Write headers
-	headers := []string{"Author", "Commit Count", "Contribution Percentage"}
-	if err := writer.Write(headers); err != nil {
-		return fmt.Errorf("failed to write author headers: %w", err)
-	}
-
-	// Get sorted list of authors
-	authors := make([]string, 0, len(metrics.CommitsByAuthor))
-	for author := range metrics.CommitsByAuthor {
-		authors = append(authors, author)
-	}
-	sort.Strings(authors)
-
-	// Write author metrics
-	for _, author := range authors {
-		commitCount := metrics.CommitsByAuthor[author]
-		percentage := float64(commitCount) / float64(metrics.TotalCommits) * 100
-
-		row := []string{
-			author,
-			strconv.Itoa(commitCount),
-			fmt.Sprintf("%.2f", percentage),
+		// Get sorted list of dates
+		dates := make([]string, 0, len(metrics.CommitsByDate))
+		for date := range metrics.CommitsByDate {
+			dates = append(dates, date)
 		}
-
-		if err := writer.Write(row); err != nil {
-			return fmt.Errorf("failed to write author row: %w", err)
+		sort.Strings(dates)
+
+		for _, date := range dates {
+			rows = append(rows, []string{
+				report.Repository,
+				date,
+				strconv.Itoa(metrics.CommitsByDate[date]),
+				strconv.Itoa(metrics.CodeChurn[date]),
+			})
 		}
 	}
 
-	return nil
+	return writeCSV(e.siblingPath("_timeline"), headers, rows)
+}
+
+// siblingPath returns the path of a file written next to the summary, with
+// suffix added to its name
+func (e *MetricsExporter) siblingPath(suffix string) string {
+	return strings.TrimSuffix(e.outputPath, ".csv") + suffix + ".csv"
 }
 
-// exportTimeBasedMetrics exports time-based metrics to a separate CSV file
-func (e *MetricsExporter) exportTimeBasedMetrics(repoPath string, metrics *RepositoryMetrics) error {
-	timeFile := strings.TrimSuffix(e.outputPath, ".csv") + "_timeline.csv"
-	file, err := os.Create(timeFile)
+// writeCSV replaces the file at path with the headers and rows
+func writeCSV(path string, headers []string, rows [][]string) error {
+	file, err := os.Create(path)
 	if err != nil {
-		return fmt.Errorf("failed to create time-based metrics file: %w", err)
+		return fmt.Errorf("failed to create CSV file: %w", err)
 	}
 	defer file.Close()
 
 	writer := csv.NewWriter(file)
-	defer writer.Flush()
-
-	// Write headers
-	headers := []string{"Date", "Commit Count", "Code Churn"}
 	if err := writer.Write(headers); err != nil {
-		return fmt.Errorf("failed to write timeline headers: %w", err)
-	}
-
-	// Get sorted list of dates
-	dates := make([]string, 0, len(metrics.CommitsByDate))
-	for date := range metrics.CommitsByDate {
-		dates = append(dates, date)
+		return fmt.Errorf("failed to write headers: %w", err)
 	}
-	sort.Strings(dates)
-
-	// Write time-based metrics
-	for _, date := range dates {
-		row := []string{
-			date,
-			strconv.Itoa(metrics.CommitsByDate[date]),
-			strconv.Itoa(metrics.CodeChurn[date]),
-		}
-
-		if err := writer.Write(row); err != nil {
-			return fmt.Errorf("failed to write timeline row: %w", err)
-		}
+	if err := writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write rows: %w", err)
 	}
-
-	return nil
+	return file.Close()
 }
 
-// ExportMultiRepoMetrics exports metrics for multiple repositories to CSV
-func ExportMultiRepoMetrics(repos []string, outputPath string) error {
-	exporter := NewMetricsExporter(outputPath)
+// ExportMultiRepoMetrics analyzes every repository and exports their metrics
+// to one set of CSV files, one section of rows per repository followed by
+// the rollup across all of them. The commits walked are selected by opts,
+// and the velocity is computed from those within timeRange. Repositories
+// that cannot be analyzed, such as empty ones, are skipped with a warning.
+func ExportMultiRepoMetrics(repos []string, outputPath string, timeRange TimeRange, opts *Options) error {
+	reports := make([]RepositoryReport, 0, len(repos))
+	all := make([]*RepositoryMetrics, 0, len(repos))
 
 	for _, repoPath := range repos {
-		analyzer, err := NewAnalyzer(repoPath)
+		log.Info("Analyzing repository", logger.KeyDir, repoPath)
+		metrics, err := analyze(repoPath, opts)
 		if err != nil {
-			return fmt.Errorf("failed to create analyzer for %s: %w", repoPath, err)
+			log.Warn("Failed to analyze repository, skipping", logger.KeyDir, repoPath, logger.KeyError, err)
+			continue
 		}
 
-		metrics, err := analyzer.AnalyzeRepository()
-		if err != nil {
-			return fmt.Errorf("failed to analyze repository %s: %w", repoPath, err)
-		}
+		reports = append(reports, RepositoryReport{
+			Repository: repoPath,
+			Metrics:    metrics,
+			Velocity:   velocity(repoPath, metrics, timeRange),
+		})
+		all = append(all, metrics)
+	}
 
-		// Calculate velocity metrics
-		timeRange := TimeRange{
-			Start: time.Now().AddDate(0, -1, 0), // Last month
-			End:   time.Now(),
-		}
+	if len(reports) == 0 && len(repos) > 0 {
+		return fmt.Errorf("none of the %d repositories could be analyzed", len(repos))
+	}
 
-		commits := make([]CommitMetrics, 0)
-		velocity := CalculateVelocity(commits, timeRange)
+	rollupMetrics := MergeMetrics(all...)
+	rollup := &RepositoryReport{
+		Repository: RollupRepository,
+		Metrics:    rollupMetrics,
+		Velocity:   velocity(RollupRepository, rollupMetrics, timeRange),
+	}
 
-		if err := exporter.ExportMetricsToCSV(repoPath, metrics, velocity); err != nil {
-			return fmt.Errorf("failed to export metrics for %s: %w", repoPath, err)
-		}
+	if err := NewMetricsExporter(outputPath).Export(reports, rollup); err != nil {
+		return fmt.Errorf("failed to export metrics: %w", err)
 	}
 
 	return nil
 }
+
+// analyze returns the metrics of the repository at repoPath
+func analyze(repoPath string, opts *Options) (*RepositoryMetrics, error) {
+	analyzer, err := NewAnalyzer(repoPath, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create analyzer for %s: %w", repoPath, err)
+	}
+
+	metrics, err := analyzer.AnalyzeRepository()
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze repository %s: %w", repoPath, err)
+	}
+	return metrics, nil
+}
+
+// velocity calculates the velocity metrics from the commits within timeRange
+func velocity(name string, metrics *RepositoryMetrics, timeRange TimeRange) VelocityMetrics {
+	commits := metrics.CommitsIn(timeRange)
+	velocity := CalculateVelocity(commits, timeRange)
+	log.Debug("Calculated velocity", logger.KeyRepo, name, "commits", len(commits),
+		"commits_per_day", velocity.CommitsPerDay, "lines_per_day", velocity.AverageLinesPerDay)
+	return velocity
+}
@@ -0,0 +1,256 @@
+package metrics
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dmaharana/clone-git-repo/internal/pkg/logger"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// mailmapFile is the name of the mailmap at the top of a repository
+const mailmapFile = ".mailmap"
+
+// DefaultBotPatterns match the names and emails of common bots, such as
+// dependabot[bot] and the noreply address GitHub commits web edits with
+var DefaultBotPatterns = []string{"*[bot]", "*[bot]@*", "noreply@*", "no-reply@*"}
+
+// IdentityOptions control how commit authors are resolved to people. The
+// zero value keys authors by their raw email.
+type IdentityOptions struct {
+	UseMailmap  bool     // honor the .mailmap of each repository
+	Aliases     *Mailmap // global aliases in mailmap format, applied after the repository's .mailmap
+	CaseFold    bool     // compare emails case-insensitively
+	BotPatterns []string // names or emails of bots, * matching any text
+	ExcludeBots bool     // leave the commits of bots out of every metric
+}
+
+// Identity is a commit author after resolution
+type Identity struct {
+	Name  string
+	Email string
+	Bot   bool
+}
+
+// identityResolver maps commit authors to identities for one repository
+type identityResolver struct {
+	opts    IdentityOptions
+	mailmap *Mailmap
+	bots    []*regexp.Regexp
+}
+
+// newIdentityResolver creates the resolver of a repository, reading its
+// .mailmap when asked to
+func newIdentityResolver(repo *git.Repository, repoPath string, opts IdentityOptions) (*identityResolver, error) {
+	r := &identityResolver{opts: opts}
+	for _, pattern := range opts.BotPatterns {
+		r.bots = append(r.bots, globToRegexp(pattern))
+	}
+
+	if opts.UseMailmap {
+		mailmap, err := readRepositoryMailmap(repo, repoPath)
+		if err != nil {
+			return nil, err
+		}
+		r.mailmap = mailmap
+	}
+	return r, nil
+}
+
+// resolve returns the identity of the author of c
+func (r *identityResolver) resolve(c *object.Commit) Identity {
+	name, email := c.Author.Name, c.Author.Email
+	if r.mailmap != nil {
+		name, email = r.mailmap.Resolve(name, email)
+	}
+	if r.opts.Aliases != nil {
+		name, email = r.opts.Aliases.Resolve(name, email)
+	}
+	if r.opts.CaseFold {
+		email = strings.ToLower(email)
+	}
+
+	id := Identity{Name: name, Email: email}
+	for _, value := range []string{c.Author.Name, c.Author.Email, name, email} {
+		if r.isBot(value) {
+			id.Bot = true
+			break
+		}
+	}
+	return id
+}
+
+// isBot reports whether a name or email matches a bot pattern
+func (r *identityResolver) isBot(value string) bool {
+	for _, bot := range r.bots {
+		if bot.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// globToRegexp compiles a case-insensitive pattern in which * matches any
+// text and every other character, brackets included, matches itself
+func globToRegexp(pattern string) *regexp.Regexp {
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.MustCompile("(?i)^" + strings.Join(parts, ".*") + "$")
+}
+
+// Mailmap maps the names and emails commits were made with to the proper
+// ones, in the format of git's .mailmap
+type Mailmap struct {
+	byEmail     map[string]mailmapEntry // entries matching the email only
+	byNameEmail map[string]mailmapEntry // entries matching name and email
+}
+
+// mailmapEntry holds the proper name and email, either may be empty to keep
+// the commit's
+type mailmapEntry struct {
+	name  string
+	email string
+}
+
+// LoadMailmap reads a mailmap file
+func LoadMailmap(path string) (*Mailmap, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mailmap: %w", err)
+	}
+	defer file.Close()
+
+	mailmap, err := ParseMailmap(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mailmap %s: %w", path, err)
+	}
+	return mailmap, nil
+}
+
+// ParseMailmap parses a mailmap. Each line holds one of
+//
+//	Proper Name <commit@email>
+//	<proper@email> <commit@email>
+//	Proper Name <proper@email> <commit@email>
+//	Proper Name <proper@email> Commit Name <commit@email>
+//
+// and '#' starts a comment. Emails are matched case-insensitively.
+func ParseMailmap(r io.Reader) (*Mailmap, error) {
+	m := &Mailmap{
+		byEmail:     make(map[string]mailmapEntry),
+		byNameEmail: make(map[string]mailmapEntry),
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		names, emails := parseMailmapLine(line)
+
+		switch len(emails) {
+		case 0:
+			continue
+		case 1:
+			// Proper Name <commit@email>
+			m.byEmail[strings.ToLower(emails[0])] = mailmapEntry{name: names[0]}
+		default:
+			entry := mailmapEntry{name: names[0], email: emails[0]}
+			if names[1] != "" {
+				m.byNameEmail[mailmapKey(names[1], emails[1])] = entry
+			} else {
+				m.byEmail[strings.ToLower(emails[1])] = entry
+			}
+		}
+	}
+	return m, scanner.Err()
+}
+
+// parseMailmapLine returns the names and emails of a mailmap line in order,
+// the name before each email being empty when absent
+func parseMailmapLine(line string) ([]string, []string) {
+	var names, emails []string
+	for len(emails) < 2 {
+		open := strings.Index(line, "<")
+		end := strings.Index(line, ">")
+		if open < 0 || end < open {
+			break
+		}
+		names = append(names, strings.TrimSpace(line[:open]))
+		emails = append(emails, strings.TrimSpace(line[open+1:end]))
+		line = line[end+1:]
+	}
+	return names, emails
+}
+
+// mailmapKey returns the key of an entry matching name and email
+func mailmapKey(name, email string) string {
+	return strings.ToLower(name) + "\x00" + strings.ToLower(email)
+}
+
+// Resolve returns the proper name and email for those of a commit. Entries
+// matching both name and email take precedence over those matching the
+// email only.
+func (m *Mailmap) Resolve(name, email string) (string, string) {
+	entry, ok := m.byNameEmail[mailmapKey(name, email)]
+	if !ok {
+		entry, ok = m.byEmail[strings.ToLower(email)]
+	}
+	if !ok {
+		return name, email
+	}
+
+	if entry.name != "" {
+		name = entry.name
+	}
+	if entry.email != "" {
+		email = entry.email
+	}
+	return name, email
+}
+
+// readRepositoryMailmap reads the .mailmap of the worktree or, for bare
+// repositories such as mirrors, the one committed at HEAD. A repository
+// without one yields nil.
+func readRepositoryMailmap(repo *git.Repository, repoPath string) (*Mailmap, error) {
+	path := filepath.Join(repoPath, mailmapFile)
+	if _, err := os.Stat(path); err == nil {
+		return LoadMailmap(path)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return nil, nil
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, nil
+	}
+	file, err := commit.File(mailmapFile)
+	if errors.Is(err, object.ErrFileNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s at HEAD: %w", mailmapFile, err)
+	}
+
+	reader, err := file.Reader()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s at HEAD: %w", mailmapFile, err)
+	}
+	defer reader.Close()
+
+	mailmap, err := ParseMailmap(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s at HEAD: %w", mailmapFile, err)
+	}
+	log.Debug("Read mailmap", logger.KeyDir, repoPath, "source", "HEAD")
+	return mailmap, nil
+}
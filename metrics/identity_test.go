@@ -0,0 +1,149 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// mustParseMailmap parses a mailmap given as a string
+func mustParseMailmap(t *testing.T, content string) *Mailmap {
+	t.Helper()
+	m, err := ParseMailmap(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestParseMailmap(t *testing.T) {
+	m := mustParseMailmap(t, `# the proper names of the team
+Alice Smith <alice@old.example.com>
+<bob@example.com> <Bob@Laptop.local>
+Carol Jones <carol@example.com> <cj@old.example.com>   # moved teams
+Dave Brown <dave@example.com> dave <shared@example.com>
+# Eve <eve@example.com> <ignored@example.com>
+not a mapping
+`)
+
+	tests := []struct {
+		name, email         string
+		wantName, wantEmail string
+	}{
+		// Proper Name <commit@email>
+		{"alice", "alice@old.example.com", "Alice Smith", "alice@old.example.com"},
+		{"alice", "ALICE@old.example.com", "Alice Smith", "ALICE@old.example.com"},
+		// <proper@email> <commit@email>
+		{"Bob", "bob@laptop.local", "Bob", "bob@example.com"},
+		// Proper Name <proper@email> <commit@email>
+		{"cj", "cj@old.example.com", "Carol Jones", "carol@example.com"},
+		// Proper Name <proper@email> Commit Name <commit@email>
+		{"Dave", "shared@example.com", "Dave Brown", "dave@example.com"},
+		{"erin", "shared@example.com", "erin", "shared@example.com"},
+		// commented out and unknown authors are left alone
+		{"Eve", "ignored@example.com", "Eve", "ignored@example.com"},
+		{"Frank", "frank@example.com", "Frank", "frank@example.com"},
+	}
+
+	for _, tt := range tests {
+		name, email := m.Resolve(tt.name, tt.email)
+		if name != tt.wantName || email != tt.wantEmail {
+			t.Errorf("Resolve(%q, %q) = %q, %q, want %q, %q", tt.name, tt.email, name, email, tt.wantName, tt.wantEmail)
+		}
+	}
+}
+
+func TestMailmapNameAndEmailTakePrecedence(t *testing.T) {
+	m := mustParseMailmap(t, `Shared Account <shared@example.com>
+Dave Brown <dave@example.com> dave <shared@example.com>
+`)
+
+	if name, email := m.Resolve("DAVE", "shared@example.com"); name != "Dave Brown" || email != "dave@example.com" {
+		t.Errorf("Resolve(DAVE) = %q, %q, want the name and email entry", name, email)
+	}
+	if name, email := m.Resolve("ci", "shared@example.com"); name != "Shared Account" || email != "shared@example.com" {
+		t.Errorf("Resolve(ci) = %q, %q, want the email only entry", name, email)
+	}
+}
+
+func TestIdentityResolver(t *testing.T) {
+	mailmap := mustParseMailmap(t, "Alice Smith <alice@example.com> <alice@laptop.local>\n")
+	aliases := mustParseMailmap(t, `Alice Smith <alice@corp.example.com> <alice@example.com>
+Build Bot <ci-bot@example.com> <jenkins@example.com>
+`)
+
+	bots := append([]string{"ci-bot@*"}, DefaultBotPatterns...)
+	tests := []struct {
+		name   string
+		opts   IdentityOptions
+		author object.Signature
+		want   Identity
+	}{
+		{
+			name:   "raw email without options",
+			opts:   IdentityOptions{},
+			author: object.Signature{Name: "alice", Email: "Alice@Laptop.local"},
+			want:   Identity{Name: "alice", Email: "Alice@Laptop.local"},
+		},
+		{
+			name:   "case fold",
+			opts:   IdentityOptions{CaseFold: true},
+			author: object.Signature{Name: "alice", Email: "Alice@Laptop.local"},
+			want:   Identity{Name: "alice", Email: "alice@laptop.local"},
+		},
+		{
+			name:   "mailmap only",
+			opts:   IdentityOptions{UseMailmap: true},
+			author: object.Signature{Name: "alice", Email: "alice@laptop.local"},
+			want:   Identity{Name: "Alice Smith", Email: "alice@example.com"},
+		},
+		{
+			name:   "aliases apply to the mailmap result",
+			opts:   IdentityOptions{UseMailmap: true, Aliases: aliases},
+			author: object.Signature{Name: "alice", Email: "alice@laptop.local"},
+			want:   Identity{Name: "Alice Smith", Email: "alice@corp.example.com"},
+		},
+		{
+			name:   "bot by raw name",
+			opts:   IdentityOptions{BotPatterns: bots},
+			author: object.Signature{Name: "dependabot[bot]", Email: "49699333+dependabot@users.noreply.github.com"},
+			want:   Identity{Name: "dependabot[bot]", Email: "49699333+dependabot@users.noreply.github.com", Bot: true},
+		},
+		{
+			name:   "bot by resolved email",
+			opts:   IdentityOptions{Aliases: aliases, BotPatterns: bots},
+			author: object.Signature{Name: "jenkins", Email: "jenkins@example.com"},
+			want:   Identity{Name: "Build Bot", Email: "ci-bot@example.com", Bot: true},
+		},
+		{
+			name:   "bot patterns ignore case",
+			opts:   IdentityOptions{BotPatterns: bots},
+			author: object.Signature{Name: "GitHub", Email: "NoReply@github.com"},
+			want:   Identity{Name: "GitHub", Email: "NoReply@github.com", Bot: true},
+		},
+		{
+			name:   "brackets match themselves",
+			opts:   IdentityOptions{BotPatterns: bots},
+			author: object.Signature{Name: "botb", Email: "b@example.com"},
+			want:   Identity{Name: "botb", Email: "b@example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &identityResolver{opts: tt.opts}
+			if tt.opts.UseMailmap {
+				r.mailmap = mailmap
+			}
+			for _, pattern := range tt.opts.BotPatterns {
+				r.bots = append(r.bots, globToRegexp(pattern))
+			}
+
+			got := r.resolve(&object.Commit{Author: tt.author})
+			if got != tt.want {
+				t.Errorf("resolve(%s <%s>) = %+v, want %+v", tt.author.Name, tt.author.Email, got, tt.want)
+			}
+		})
+	}
+}
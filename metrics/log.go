@@ -0,0 +1,15 @@
+package metrics
+
+import (
+	"github.com/dmaharana/clone-git-repo/internal/pkg/logger"
+)
+
+// log receives the messages of this package. It writes to stderr until a
+// logger is injected with SetLogger.
+var log = logger.Default()
+
+// SetLogger makes the package log through l. It must be called before any
+// repository is analyzed.
+func SetLogger(l *logger.Logger) {
+	log = l
+}
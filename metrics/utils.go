@@ -12,6 +12,22 @@ type TimeRange struct {
 	End   time.Time
 }
 
+// LastDays returns the time range of the given number of days up to now
+func LastDays(days int) TimeRange {
+	end := time.Now()
+	return TimeRange{Start: end.AddDate(0, 0, -days), End: end}
+}
+
+// Contains reports whether t falls within the time range, start included
+func (r TimeRange) Contains(t time.Time) bool {
+	return !t.Before(r.Start) && !t.After(r.End)
+}
+
+// Days returns the length of the time range in days
+func (r TimeRange) Days() float64 {
+	return r.End.Sub(r.Start).Hours() / 24
+}
+
 // VelocityMetrics represents the development velocity metrics
 type VelocityMetrics struct {
 	CommitsPerDay      float64
@@ -30,7 +46,7 @@ func CalculateVelocity(commits []CommitMetrics, timeRange TimeRange) VelocityMet
 		return commits[i].Date.Before(commits[j].Date)
 	})
 
-	totalDays := timeRange.End.Sub(timeRange.Start).Hours() / 24
+	totalDays := timeRange.Days()
 	if totalDays < 1 {
 		totalDays = 1
 	}
@@ -53,7 +69,11 @@ func CalculateVelocity(commits []CommitMetrics, timeRange TimeRange) VelocityMet
 		sumXX += x * x
 	}
 
-	slope := (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX)
+	// a single commit has no trend
+	var slope float64
+	if denominator := n*sumXX - sumX*sumX; denominator != 0 {
+		slope = (n*sumXY - sumX*sumY) / denominator
+	}
 
 	return VelocityMetrics{
 		CommitsPerDay:      float64(len(commits)) / totalDays,
@@ -62,6 +82,45 @@ func CalculateVelocity(commits []CommitMetrics, timeRange TimeRange) VelocityMet
 	}
 }
 
+// MergeMetrics combines the metrics of several repositories into one, as if
+// their histories were a single repository
+func MergeMetrics(metrics ...*RepositoryMetrics) *RepositoryMetrics {
+	merged := &RepositoryMetrics{
+		UniqueAuthors:   make(map[string]bool),
+		CommitsByAuthor: make(map[string]int),
+		CommitsByDate:   make(map[string]int),
+		CodeChurn:       make(map[string]int),
+		CommitFrequency: make(map[string]int),
+	}
+
+	totalLines := 0
+	for _, m := range metrics {
+		merged.TotalCommits += m.TotalCommits
+		mergeCounts(merged.CommitsByAuthor, m.CommitsByAuthor)
+		mergeCounts(merged.CommitsByDate, m.CommitsByDate)
+		mergeCounts(merged.CodeChurn, m.CodeChurn)
+		mergeCounts(merged.CommitFrequency, m.CommitFrequency)
+		for author := range m.UniqueAuthors {
+			merged.UniqueAuthors[author] = true
+		}
+		merged.TimeBetweenCommits = append(merged.TimeBetweenCommits, m.TimeBetweenCommits...)
+		merged.Commits = append(merged.Commits, m.Commits...)
+		totalLines += int(m.AverageCommitSize*float64(m.TotalCommits) + 0.5)
+	}
+
+	if merged.TotalCommits > 0 {
+		merged.AverageCommitSize = float64(totalLines) / float64(merged.TotalCommits)
+	}
+	return merged
+}
+
+// mergeCounts adds the counts of src to dst
+func mergeCounts(dst, src map[string]int) {
+	for key, n := range src {
+		dst[key] += n
+	}
+}
+
 // CalculateCommitDistribution returns the distribution of commits across different time periods
 func CalculateCommitDistribution(commits []CommitMetrics) map[string]int {
 	distribution := make(map[string]int)
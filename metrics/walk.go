@@ -0,0 +1,167 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dmaharana/clone-git-repo/internal/pkg/logger"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Options select the commits an Analyzer walks. The zero value walks the
+// history of HEAD, merge commits included.
+type Options struct {
+	AllRefs       bool      // walk every branch, remote branch and tag instead of HEAD
+	Branches      []string  // walk these branches instead of HEAD, local or on origin
+	Since         time.Time // skip commits authored before this time, if set
+	Until         time.Time // skip commits authored after this time, if set
+	ExcludeMerges bool      // skip commits with more than one parent
+	Identity      IdentityOptions
+}
+
+// forEachCommit calls fn for every commit selected by the options with its
+// resolved author, newest first by author date. Commits reachable from
+// several refs are visited once.
+func (a *Analyzer) forEachCommit(fn func(c *object.Commit, author Identity) error) error {
+	starts, err := a.startCommits()
+	if err != nil {
+		return err
+	}
+
+	type selected struct {
+		commit *object.Commit
+		author Identity
+	}
+	var commits []selected
+	seen := make(map[plumbing.Hash]bool)
+	stack := starts
+	for len(stack) > 0 {
+		hash := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[hash] {
+			continue
+		}
+		seen[hash] = true
+
+		c, err := a.repo.CommitObject(hash)
+		if errors.Is(err, plumbing.ErrObjectNotFound) {
+			// the history of shallow clones ends early
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read commit %s: %w", hash, err)
+		}
+		stack = append(stack, c.ParentHashes...)
+
+		author := a.ids.resolve(c)
+		if a.includes(c, author) {
+			commits = append(commits, selected{commit: c, author: author})
+		}
+	}
+
+	sort.SliceStable(commits, func(i, j int) bool {
+		return commits[i].commit.Author.When.After(commits[j].commit.Author.When)
+	})
+	for _, s := range commits {
+		if err := fn(s.commit, s.author); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// includes reports whether the options select the commit
+func (a *Analyzer) includes(c *object.Commit, author Identity) bool {
+	when := c.Author.When
+	switch {
+	case a.opts.Identity.ExcludeBots && author.Bot:
+		return false
+	case a.opts.ExcludeMerges && c.NumParents() > 1:
+		return false
+	case !a.opts.Since.IsZero() && when.Before(a.opts.Since):
+		return false
+	case !a.opts.Until.IsZero() && when.After(a.opts.Until):
+		return false
+	}
+	return true
+}
+
+// startCommits returns the commits the walk starts from
+func (a *Analyzer) startCommits() ([]plumbing.Hash, error) {
+	switch {
+	case a.opts.AllRefs:
+		return a.refCommits()
+	case len(a.opts.Branches) > 0:
+		return a.branchCommits()
+	}
+
+	ref, err := a.repo.Head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repository head: %w", err)
+	}
+	return []plumbing.Hash{ref.Hash()}, nil
+}
+
+// refCommits returns the commits of every branch, remote branch and tag,
+// with annotated tags peeled. Other refs, such as notes, stashes and the
+// pull request heads kept by mirrors, are not authored work and are skipped.
+func (a *Analyzer) refCommits() ([]plumbing.Hash, error) {
+	refs, err := a.repo.References()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list references: %w", err)
+	}
+
+	var hashes []plumbing.Hash
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		name := ref.Name()
+		if ref.Type() != plumbing.HashReference || !(name.IsBranch() || name.IsRemote() || name.IsTag()) {
+			return nil
+		}
+		hash := ref.Hash()
+		if tag, err := a.repo.TagObject(hash); err == nil {
+			c, err := tag.Commit()
+			if err != nil {
+				// a tag of a tree or blob has no history
+				return nil
+			}
+			hash = c.Hash
+		}
+		hashes = append(hashes, hash)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list references: %w", err)
+	}
+	if len(hashes) == 0 {
+		return nil, fmt.Errorf("repository has no commits")
+	}
+	return hashes, nil
+}
+
+// branchCommits returns the tips of the selected branches, looked up
+// locally first and on origin otherwise. Branches the repository does not
+// have are skipped.
+func (a *Analyzer) branchCommits() ([]plumbing.Hash, error) {
+	var hashes []plumbing.Hash
+	for _, branch := range a.opts.Branches {
+		ref, err := a.repo.Reference(plumbing.NewBranchReferenceName(branch), true)
+		if errors.Is(err, plumbing.ErrReferenceNotFound) {
+			ref, err = a.repo.Reference(plumbing.NewRemoteReferenceName("origin", branch), true)
+		}
+		if errors.Is(err, plumbing.ErrReferenceNotFound) {
+			log.Debug("Branch not found", logger.KeyDir, a.path, "branch", branch)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve branch %s: %w", branch, err)
+		}
+		hashes = append(hashes, ref.Hash())
+	}
+	if len(hashes) == 0 {
+		return nil, fmt.Errorf("none of the branches %v found", a.opts.Branches)
+	}
+	return hashes, nil
+}